@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Barrier is a cyclic barrier for n goroutines: each calls Await(), and all of them are released together once
+// the n-th arrives. The barrier then starts a new generation for the next round. An optional action is executed
+// exactly once per generation, by the last goroutine to arrive, before the others are released.
+// Clients should use NewBarrier to create objects.
+type Barrier struct {
+	mu     sync.Mutex
+	n      int
+	action *Once       // template of the Once executing the action in each generation, nil if there is no action
+	gen    *barrierGen // guarded by mu
+	closed bool        // guarded by mu
+}
+
+// barrierGen is the state of a generation of a Barrier.
+type barrierGen struct {
+	arrived  int           // guarded by Barrier.mu
+	action   *Once         // executes the action of the Barrier for this generation
+	released chan struct{} // closed once the generation is complete or the Barrier is closed
+	tripped  bool          // the generation completed, as opposed to the Barrier being closed. guarded by Barrier.mu
+}
+
+// NewBarrier returns a Barrier for n goroutines. action, if not nil, is executed once per generation.
+// An error is returned if n isn't positive.
+func NewBarrier(n int, action func()) (*Barrier, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of goroutines needs to be positive, got %d", n)
+	}
+
+	b := &Barrier{n: n}
+	if action != nil {
+		o, err := NewDefaultOnce(func() bool {
+			action()
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		b.action = o
+	}
+	b.gen = b.newGen()
+	return b, nil
+}
+
+// Await blocks till n goroutines have called it in the current generation and returns nil.
+// It returns ErrClosed if the Barrier is closed before that. If the action panics, the panic is raised in the
+// goroutine which executed it, and the others are released all the same.
+func (b *Barrier) Await() error {
+	return b.AwaitCtx(context.Background())
+}
+
+// AwaitCtx is same as Await() but gives up when ctx is done, in which case ctx.Err() is returned and the goroutine
+// doesn't count as arrived anymore. If the generation completes at the same time, nil is returned.
+func (b *Barrier) AwaitCtx(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	g := b.gen
+	g.arrived++
+	if g.arrived == b.n {
+		g.tripped = true
+		b.gen = b.newGen()
+		b.mu.Unlock()
+
+		defer close(g.released)
+		if g.action != nil {
+			g.action.Do()
+		}
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-g.released:
+	case <-ctx.Done():
+		b.mu.Lock()
+		tripped := g.tripped
+		if !tripped && !b.closed {
+			g.arrived--
+		}
+		b.mu.Unlock()
+		if tripped {
+			// released as soon as the action is done
+			<-g.released
+			return nil
+		}
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !g.tripped {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Waiting returns the number of goroutines blocked in the current generation.
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gen.arrived
+}
+
+// Close releases the goroutines blocked in the current generation with ErrClosed. Await() fails after Close.
+func (b *Barrier) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.gen.released)
+}
+
+// newGen returns the state of a new generation. Must be called holding mu, or before the Barrier is shared.
+func (b *Barrier) newGen() *barrierGen {
+	g := &barrierGen{released: make(chan struct{})}
+	if b.action != nil {
+		g.action = b.action.Clone()
+	}
+	return g
+}
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBarrier(t *testing.T) {
+	_, err := NewBarrier(0, nil)
+	assert.NotEqual(t, err, nil)
+
+	const n, rounds = 4, 3
+	var actions, arrived int32
+	b, err := NewBarrier(n, func() {
+		// all goroutines of the generation have arrived when the action runs
+		assert.Equal(t, int32(0), atomic.LoadInt32(&arrived)%n)
+		atomic.AddInt32(&actions, 1)
+	})
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				atomic.AddInt32(&arrived, 1)
+				assert.Equal(t, nil, b.Await())
+				// nobody is released before the whole generation arrived
+				assert.True(t, atomic.LoadInt32(&arrived) >= int32((r+1)*n))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(rounds), atomic.LoadInt32(&actions))
+	assert.Equal(t, 0, b.Waiting())
+}
+
+func TestBarrierAwaitCtx(t *testing.T) {
+	b, err := NewBarrier(2, nil)
+	assert.Equal(t, err, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.AwaitCtx(ctx))
+	assert.Equal(t, 0, b.Waiting())
+
+	// the goroutine which gave up doesn't count towards the next generation
+	done := make(chan error)
+	go func() { done <- b.Await() }()
+	for b.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, nil, b.Await())
+	assert.Equal(t, nil, <-done)
+}
+
+func TestBarrierClose(t *testing.T) {
+	b, err := NewBarrier(3, func() { t.Fatal("action of an incomplete generation executed") })
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, ErrClosed, b.Await())
+		}()
+	}
+	for b.Waiting() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Close()
+	b.Close()
+	wg.Wait()
+	assert.Equal(t, ErrClosed, b.Await())
+}
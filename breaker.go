@@ -0,0 +1,208 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBreakerOpen is returned by CircuitBreaker.Execute when the call is rejected as the breaker is open.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker as reported by CircuitBreaker.State.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // calls go through, failures are counted
+	BreakerOpen                         // calls are rejected till the open timeout elapses
+	BreakerHalfOpen                     // a limited number of trial calls go through, to probe for recovery
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "Closed"
+	case BreakerOpen:
+		return "Open"
+	case BreakerHalfOpen:
+		return "HalfOpen"
+	default:
+		return "BreakerState(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// BreakerSettings configure a CircuitBreaker.
+type BreakerSettings struct {
+	FailureThreshold int                         // consecutive failures opening the breaker. Required
+	OpenTimeout      time.Duration               // time the breaker stays open before going half open. Required
+	HalfOpenCalls    int                         // trial calls which need to succeed to close the breaker. Defaults to 1
+	IsFailure        func(err error) bool        // whether err counts as a failure. Defaults to err != nil
+	OnStateChange    func(from, to BreakerState) // called after each state change, without holding any lock
+	Clock            Clock                       // measures OpenTimeout. Defaults to RealClock
+}
+
+// CircuitBreaker stops calling a failing dependency for a while: it opens after FailureThreshold consecutive
+// failures, rejecting calls with ErrBreakerOpen, and after OpenTimeout lets HalfOpenCalls trial calls through.
+// It closes again if they all succeed and reopens on the first one failing. Clients should use NewCircuitBreaker
+// to create objects.
+type CircuitBreaker struct {
+	settings BreakerSettings
+	state    int32 // BreakerState, readable without mu. written holding mu
+
+	mu        sync.Mutex
+	failures  int           // consecutive failures while closed, successes while half open. guarded by mu
+	trials    int           // trial calls let through while half open. guarded by mu
+	openUntil time.Time     // guarded by mu
+	gen       uint64        // bumped on each state change, to ignore results of calls from before. guarded by mu
+	changed   chan struct{} // closed on each state change, then replaced. guarded by mu
+	pending   []func()      // OnStateChange calls to make once mu is released. guarded by mu
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker. An error is returned if FailureThreshold or OpenTimeout isn't
+// positive, or HalfOpenCalls is negative.
+func NewCircuitBreaker(settings BreakerSettings) (*CircuitBreaker, error) {
+	if settings.FailureThreshold <= 0 {
+		return nil, fmt.Errorf("failure threshold needs to be positive, got %d", settings.FailureThreshold)
+	}
+	if settings.OpenTimeout <= 0 {
+		return nil, fmt.Errorf("open timeout needs to be positive, got %v", settings.OpenTimeout)
+	}
+	if settings.HalfOpenCalls < 0 {
+		return nil, fmt.Errorf("half open calls can't be negative, got %d", settings.HalfOpenCalls)
+	}
+	if settings.HalfOpenCalls == 0 {
+		settings.HalfOpenCalls = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(err error) bool { return err != nil }
+	}
+	settings.Clock = clockOrReal(settings.Clock)
+	return &CircuitBreaker{settings: settings, changed: make(chan struct{})}, nil
+}
+
+// Execute calls fn if the breaker lets the call through and records its outcome. It returns the error of fn, or
+// ErrBreakerOpen without calling fn if the breaker is open or all the trial calls of the half open state are in
+// progress. A panic in fn counts as a failure and is raised in the caller.
+func (b *CircuitBreaker) Execute(fn func() error) (err error) {
+	gen, ok := b.admit()
+	if !ok {
+		return ErrBreakerOpen
+	}
+	failed := true
+	defer func() {
+		b.record(gen, failed)
+	}()
+	err = fn()
+	failed = b.settings.IsFailure(err)
+	return err
+}
+
+// State returns the state of the breaker. It never blocks. An open breaker whose timeout has elapsed is reported
+// as open till the next call goes through it.
+func (b *CircuitBreaker) State() BreakerState {
+	return BreakerState(atomic.LoadInt32(&b.state))
+}
+
+// Wait blocks while the breaker is open and returns nil once it lets calls through again, or ctx.Err() if ctx
+// is done first. A call made after Wait returns can still be rejected by a concurrent change of state.
+func (b *CircuitBreaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.expire(b.settings.Clock.Now())
+		if b.State() != BreakerOpen {
+			b.unlock()
+			return nil
+		}
+		changed, delay := b.changed, b.openUntil.Sub(b.settings.Clock.Now())
+		b.unlock()
+
+		t := b.settings.Clock.NewTimer(delay)
+		select {
+		case <-changed:
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		t.Stop()
+	}
+}
+
+// admit returns whether a call can go through and the generation it belongs to.
+func (b *CircuitBreaker) admit() (uint64, bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	b.expire(b.settings.Clock.Now())
+	switch b.State() {
+	case BreakerOpen:
+		return 0, false
+	case BreakerHalfOpen:
+		if b.trials >= b.settings.HalfOpenCalls {
+			return 0, false
+		}
+		b.trials++
+	}
+	return b.gen, true
+}
+
+// record records the outcome of a call admitted in generation gen.
+func (b *CircuitBreaker) record(gen uint64, failed bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	if gen != b.gen {
+		// the state changed since the call was admitted
+		return
+	}
+	switch b.State() {
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+		} else if b.failures++; b.failures >= b.settings.FailureThreshold {
+			b.setState(BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		if failed {
+			b.setState(BreakerOpen)
+		} else if b.failures++; b.failures >= b.settings.HalfOpenCalls {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// expire moves an open breaker to half open once its timeout has elapsed. Must be called holding mu.
+func (b *CircuitBreaker) expire(now time.Time) {
+	if b.State() == BreakerOpen && !now.Before(b.openUntil) {
+		b.setState(BreakerHalfOpen)
+	}
+}
+
+// setState changes the state and resets the counters for it. Must be called holding mu.
+func (b *CircuitBreaker) setState(to BreakerState) {
+	from := b.State()
+	atomic.StoreInt32(&b.state, int32(to))
+	b.failures, b.trials = 0, 0
+	b.gen++
+	if to == BreakerOpen {
+		b.openUntil = b.settings.Clock.Now().Add(b.settings.OpenTimeout)
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+	if f := b.settings.OnStateChange; f != nil {
+		b.pending = append(b.pending, func() { f(from, to) })
+	}
+}
+
+// unlock releases mu and then makes the pending OnStateChange calls, so that they can use the breaker.
+// Changes made concurrently by other goroutines may be reported out of order.
+func (b *CircuitBreaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+	for _, f := range pending {
+		f()
+	}
+}
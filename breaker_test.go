@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCircuitBreaker(t *testing.T) {
+	_, err := NewCircuitBreaker(BreakerSettings{OpenTimeout: time.Second})
+	assert.NotEqual(t, err, nil)
+	_, err = NewCircuitBreaker(BreakerSettings{FailureThreshold: 1})
+	assert.NotEqual(t, err, nil)
+	_, err = NewCircuitBreaker(BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenCalls: -1})
+	assert.NotEqual(t, err, nil)
+
+	assert.Equal(t, "HalfOpen", BreakerHalfOpen.String())
+	assert.Equal(t, "BreakerState(7)", BreakerState(7).String())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+	c := NewManualClock(clockEpoch)
+	b, err := NewCircuitBreaker(BreakerSettings{
+		FailureThreshold: 2,
+		OpenTimeout:      10 * time.Millisecond,
+		HalfOpenCalls:    2,
+		Clock:            c,
+		OnStateChange: func(from, to BreakerState) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+	assert.Equal(t, err, nil)
+	errFail := errors.New("fail")
+	fail := func() error { return errFail }
+	succeed := func() error { return nil }
+
+	// a success resets the consecutive failures
+	assert.Equal(t, errFail, b.Execute(fail))
+	assert.Equal(t, nil, b.Execute(succeed))
+	assert.Equal(t, errFail, b.Execute(fail))
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.Equal(t, errFail, b.Execute(fail))
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Equal(t, ErrBreakerOpen, b.Execute(succeed))
+
+	// half open, a failed trial reopens
+	c.Advance(9 * time.Millisecond)
+	assert.Equal(t, ErrBreakerOpen, b.Execute(succeed))
+	c.Advance(time.Millisecond)
+	assert.Equal(t, errFail, b.Execute(fail))
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// half open, the trials succeed
+	c.Advance(10 * time.Millisecond)
+	assert.Equal(t, nil, b.Wait(context.Background()))
+	assert.Equal(t, nil, b.Execute(succeed))
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.Equal(t, nil, b.Execute(succeed))
+	assert.Equal(t, BreakerClosed, b.State())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"Closed->Open", "Open->HalfOpen", "HalfOpen->Open", "Open->HalfOpen", "HalfOpen->Closed"}, changes)
+}
+
+func TestCircuitBreakerHalfOpenLimit(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	b, _ := NewCircuitBreaker(BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Millisecond, Clock: c})
+	assert.Panics(t, func() { b.Execute(func() error { panic("boom") }) })
+	assert.Equal(t, BreakerOpen, b.State())
+	c.Advance(time.Millisecond)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go b.Execute(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	// the only trial call is in progress
+	assert.Equal(t, ErrBreakerOpen, b.Execute(func() error { return nil }))
+	close(release)
+}
+
+func TestCircuitBreakerIsFailureAndWait(t *testing.T) {
+	errIgnored := errors.New("not found")
+	c := NewManualClock(clockEpoch)
+	b, _ := NewCircuitBreaker(BreakerSettings{
+		FailureThreshold: 1,
+		OpenTimeout:      50 * time.Millisecond,
+		Clock:            c,
+		IsFailure:        func(err error) bool { return err != nil && err != errIgnored },
+	})
+	assert.Equal(t, errIgnored, b.Execute(func() error { return errIgnored }))
+	assert.Equal(t, BreakerClosed, b.State())
+	b.Execute(func() error { return errors.New("fail") })
+	assert.Equal(t, BreakerOpen, b.State())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, b.Wait(ctx))
+
+	waited := make(chan error)
+	go func() { waited <- b.Wait(context.Background()) }()
+	waitTimers(c, 1)
+	c.Advance(49 * time.Millisecond)
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while open")
+	default:
+	}
+	c.Advance(time.Millisecond)
+	assert.Equal(t, nil, <-waited)
+}
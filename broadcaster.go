@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster delivers each published value to all its subscribers, each having its own channel. Close() ends all
+// the subscriptions by closing their channels, exactly once as it's enforced by a Once, and can be called while
+// values are being published. Clients should use NewBroadcaster to create objects.
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex                // held for reading while publishing, so that channels aren't closed during a send
+	subs   map[*subscriber[T]]struct{} // guarded by mu
+	closed bool                        // guarded by mu
+	done   chan struct{}               // closed first by Close(), to unblock the publishers
+	o      *Once
+}
+
+type subscriber[T any] struct {
+	ch       chan T
+	canceled chan struct{} // closed first by the cancel func, to unblock the publishers
+	cancel   sync.Once
+}
+
+// NewBroadcaster returns a Broadcaster with no subscribers.
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	b := &Broadcaster[T]{subs: make(map[*subscriber[T]]struct{}), done: make(chan struct{})}
+	// lazyDone = true, so the Broadcaster becomes DONE only after the channels are closed
+	b.o, _ = NewOnce(true, false, VerifyNone, func() bool {
+		close(b.done)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.closed = true
+		for s := range b.subs {
+			close(s.ch)
+		}
+		b.subs = nil
+		return true
+	})
+	return b
+}
+
+// Subscribe returns a channel receiving the values published from now on, with a buffer of size buffer, and a func
+// ending the subscription. The channel is closed when the subscription ends, either by the func, which can be
+// called more than once, or by Close(). If the Broadcaster is closed already, the channel is closed right away.
+// A negative buffer is treated as 0.
+func (b *Broadcaster[T]) Subscribe(buffer int) (<-chan T, func()) {
+	if buffer < 0 {
+		buffer = 0
+	}
+	s := &subscriber[T]{ch: make(chan T, buffer), canceled: make(chan struct{})}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	b.subs[s] = struct{}{}
+	return s.ch, func() { b.unsubscribe(s) }
+}
+
+// Publish sends v to all the subscribers, blocking till each one has received or buffered it, ctx is done or the
+// Broadcaster is closed. Subscribers which end their subscription meanwhile are skipped. It returns nil once v is
+// delivered, ErrClosed if the Broadcaster is closed first and ctx.Err() if ctx is done first, in which case some
+// subscribers may have got v already.
+func (b *Broadcaster[T]) Publish(ctx context.Context, v T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	for s := range b.subs {
+		select {
+		case s.ch <- v:
+		case <-s.canceled:
+		case <-b.done:
+			return ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// TryPublish sends v to the subscribers which can receive or buffer it right away, skipping the others, and
+// returns the number of subscribers it was sent to. It never blocks on a subscriber.
+func (b *Broadcaster[T]) TryPublish(v T) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	n := 0
+	for s := range b.subs {
+		select {
+		case s.ch <- v:
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+// Len returns the number of subscribers.
+func (b *Broadcaster[T]) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close ends all the subscriptions and makes the pending and later publishes fail. It returns true for the call
+// which closed the Broadcaster. Other calls block till it's closed and return false.
+func (b *Broadcaster[T]) Close() bool {
+	if b.o.Do() {
+		return true
+	}
+	b.o.Done(true)
+	return false
+}
+
+// Closed returns whether the Broadcaster is closed. If block = true, it blocks till it is.
+func (b *Broadcaster[T]) Closed(block bool) bool {
+	return b.o.Done(block)
+}
+
+func (b *Broadcaster[T]) unsubscribe(s *subscriber[T]) {
+	s.cancel.Do(func() {
+		close(s.canceled)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[s]; ok {
+			delete(b.subs, s)
+			close(s.ch)
+		}
+	})
+}
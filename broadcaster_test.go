@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster[int]()
+	c1, cancel1 := b.Subscribe(1)
+	c2, cancel2 := b.Subscribe(1)
+	assert.Equal(t, 2, b.Len())
+
+	assert.Equal(t, nil, b.Publish(context.Background(), 1))
+	assert.Equal(t, 1, <-c1)
+	assert.Equal(t, 1, <-c2)
+
+	cancel1()
+	cancel1()
+	_, ok := <-c1
+	assert.Equal(t, false, ok)
+	assert.Equal(t, 1, b.Len())
+
+	assert.Equal(t, 1, b.TryPublish(2))
+	assert.Equal(t, 0, b.TryPublish(3))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.Publish(ctx, 3))
+	assert.Equal(t, 2, <-c2)
+
+	assert.Equal(t, true, b.Close())
+	assert.Equal(t, false, b.Close())
+	assert.Equal(t, true, b.Closed(false))
+	_, ok = <-c2
+	assert.Equal(t, false, ok)
+	cancel2()
+	assert.Equal(t, ErrClosed, b.Publish(context.Background(), 4))
+
+	c3, _ := b.Subscribe(0)
+	_, ok = <-c3
+	assert.Equal(t, false, ok)
+}
+
+func TestBroadcasterCloseUnblocksPublish(t *testing.T) {
+	b := NewBroadcaster[int]()
+	c, cancel := b.Subscribe(0)
+	errs := make(chan error)
+	go func() { errs <- b.Publish(context.Background(), 1) }()
+
+	// a cancel unblocks the publish too
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	assert.Equal(t, nil, <-errs)
+	_, ok := <-c
+	assert.Equal(t, false, ok)
+
+	b.Subscribe(0)
+	go func() { errs <- b.Publish(context.Background(), 2) }()
+	time.Sleep(5 * time.Millisecond)
+	b.Close()
+	assert.Equal(t, ErrClosed, <-errs)
+}
+
+func TestBroadcasterConcurrent(t *testing.T) {
+	b := NewBroadcaster[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		c, _ := b.Subscribe(0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for range c {
+				n++
+			}
+			assert.Equal(t, 100, n)
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, nil, b.Publish(context.Background(), i))
+	}
+	b.Close()
+	wg.Wait()
+}
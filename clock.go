@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of the time based primitives: the automatic reset, retries and timeouts of a Once,
+// Debouncer, Throttler, Limiter, Coalescer, CircuitBreaker, Retry and the timeouts of the mutexes, Cond and
+// WaitGroup. RealClock is the clock of the time package. ManualClock is a fake one whose
+// time only moves when told to, for deterministic tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer of a Clock, same as time.Timer except that its channel is returned by C(). The channel of a
+// Timer created with AfterFunc is nil.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// clockOrReal returns c, or RealClock if c is nil.
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return RealClock{}
+	}
+	return c
+}
+
+// timeoutContext is context.WithTimeout with the timeout measured by clock. Its Err() is context.DeadlineExceeded
+// once the timeout has elapsed, but it has no Deadline() unless clock is a RealClock, as the time of clock may not
+// be the real one.
+func timeoutContext(clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(RealClock); ok {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	t := clock.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	return clockContext{ctx}, func() {
+		t.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// clockContext is the context of timeoutContext, whose Err() is the cause of its cancellation.
+type clockContext struct {
+	context.Context
+}
+
+func (c clockContext) Err() error {
+	if c.Context.Err() == nil {
+		return nil
+	}
+	return context.Cause(c.Context)
+}
+
+// RealClock is the Clock of the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTimer returns a Timer over time.NewTimer(d).
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// AfterFunc returns a Timer over time.AfterFunc(d, f).
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// ManualClock is a Clock whose time only moves with Advance() and Set(), so that tests of time based code neither
+// sleep nor depend on the load of the machine. Timers fire when the time gets to their deadline: the channel of
+// a Timer receives the time without blocking, same as time.Timer, and the function of an AfterFunc Timer is called
+// by the goroutine moving the time, so it has returned by the time Advance() does. That goroutine must not hold
+// locks the functions take. Clients should use NewManualClock to create objects.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time      // guarded by mu
+	timers []*manualTimer // pending timers by deadline. guarded by mu
+}
+
+type manualTimer struct {
+	c    *ManualClock
+	ch   chan time.Time
+	f    func()
+	when time.Time // guarded by c.mu
+}
+
+// NewManualClock returns a ManualClock whose time is now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the time of c.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns the channel of a new Timer.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer returns a Timer whose channel receives the time of c once it has advanced by d.
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	t := &manualTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc returns a Timer calling f once c has advanced by d. If d isn't positive, f is called right away in its
+// own goroutine, same as with time.AfterFunc.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &manualTimer{c: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the time of c forward by d, firing the timers which get to their deadline in the order of their
+// deadlines.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	now := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(now)
+}
+
+// Set moves the time of c to now, firing the timers which get to their deadline in the order of their deadlines.
+// The time can't go back: a time before the current one only fires the timers due already.
+func (c *ManualClock) Set(now time.Time) {
+	for {
+		c.mu.Lock()
+		if len(c.timers) == 0 || c.timers[0].when.After(now) {
+			if now.After(c.now) {
+				c.now = now
+			}
+			c.mu.Unlock()
+			return
+		}
+		// the time moves to each deadline in turn, so the timers reset by a function fire at the right time
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		at := c.now
+		c.mu.Unlock()
+		t.fire(at)
+	}
+}
+
+// Timers returns the number of timers pending, e.g. for a test to know when the code under test is waiting.
+func (c *ManualClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// remove drops t from the pending timers and returns whether it was pending. Must be called holding mu.
+func (c *ManualClock) remove(t *manualTimer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *manualTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.remove(t)
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	c := t.c
+	c.mu.Lock()
+	active := c.remove(t)
+	if d <= 0 {
+		now := c.now
+		c.mu.Unlock()
+		if t.f != nil {
+			go t.f()
+		} else {
+			t.fire(now)
+		}
+		return active
+	}
+	t.when = c.now.Add(d)
+	i := sort.Search(len(c.timers), func(i int) bool { return c.timers[i].when.After(t.when) })
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = t
+	c.mu.Unlock()
+	return active
+}
+
+// fire calls the function of t or sends at on its channel, dropping it if the channel is full as time.Timer does.
+func (t *manualTimer) fire(at time.Time) {
+	if t.f != nil {
+		t.f()
+		return
+	}
+	select {
+	case t.ch <- at:
+	default:
+	}
+}
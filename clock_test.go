@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var clockEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// waitTimers waits till n timers of c are pending, i.e. the code under test is waiting for c.
+func waitTimers(c *ManualClock, n int) {
+	for c.Timers() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// advanceWhile calls f, which is expected to wait on a new timer of c, and advances c by d once the timer is
+// created. Returns the result of f.
+func advanceWhile(c *ManualClock, d time.Duration, f func() bool) bool {
+	n := c.Timers()
+	res := make(chan bool)
+	go func() { res <- f() }()
+	waitTimers(c, n+1)
+	c.Advance(d)
+	return <-res
+}
+
+func TestManualClock(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	assert.Equal(t, clockEpoch, c.Now())
+
+	t1 := c.NewTimer(2 * time.Second)
+	after := c.After(time.Second)
+	var fired []time.Time
+	c.AfterFunc(3*time.Second, func() { fired = append(fired, c.Now()) })
+	assert.Equal(t, 3, c.Timers())
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-after:
+		t.Fatal("fired early")
+	default:
+	}
+	c.Advance(time.Millisecond)
+	assert.Equal(t, clockEpoch.Add(time.Second), <-after)
+	assert.Equal(t, 2, c.Timers())
+
+	assert.Equal(t, true, t1.Stop())
+	assert.Equal(t, false, t1.Stop())
+	assert.Equal(t, false, t1.Reset(time.Second))
+	c.Advance(5 * time.Second)
+	assert.Equal(t, clockEpoch.Add(2*time.Second), <-t1.C())
+	// the function has returned, seeing the time of its deadline
+	assert.Equal(t, []time.Time{clockEpoch.Add(3 * time.Second)}, fired)
+	assert.Equal(t, clockEpoch.Add(6*time.Second), c.Now())
+	assert.Equal(t, 0, c.Timers())
+
+	// the time doesn't go back
+	c.Set(clockEpoch)
+	assert.Equal(t, clockEpoch.Add(6*time.Second), c.Now())
+
+	done := make(chan struct{})
+	c.AfterFunc(0, func() { close(done) })
+	<-done
+}
+
+func TestManualClockOnceTTL(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	runs := 0
+	o, err := NewOnceWithOptions([]FuncType{func() bool {
+		runs++
+		c.Advance(time.Second) // time spent executing
+		return true
+	}}, WithResetAfter(time.Minute), WithClock(c))
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, time.Second, o.Duration())
+	c.Advance(time.Minute - time.Nanosecond)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, true, o.Done(false))
+	c.Advance(time.Nanosecond)
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, 2, runs)
+}
+
+func TestManualClockDebounce(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	calls := 0
+	db := DebounceWithClock(c, time.Second, func() { calls++ })
+	db.Call()
+	c.Advance(900 * time.Millisecond)
+	db.Call()
+	c.Advance(900 * time.Millisecond)
+	assert.Equal(t, 0, calls)
+	c.Advance(100 * time.Millisecond)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	th := ThrottleWithClock(c, time.Second, func() { calls++ })
+	th.Call()
+	assert.Equal(t, 1, calls)
+	th.Call()
+	th.Call()
+	c.Advance(999 * time.Millisecond)
+	assert.Equal(t, 1, calls)
+	c.Advance(time.Millisecond)
+	assert.Equal(t, 2, calls)
+	th.Call()
+	assert.Equal(t, 2, calls) // within the interval of the trailing execution
+	assert.Equal(t, true, th.Stop())
+}
+
+func TestManualClockLimiter(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	l, err := NewLimiterWithClock(c, 2, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, l.Allow())
+	assert.Equal(t, false, l.Allow())
+	c.Advance(500 * time.Millisecond)
+	assert.Equal(t, true, l.Allow())
+
+	r := l.Reserve()
+	assert.Equal(t, 500*time.Millisecond, r.Delay())
+	c.Advance(200 * time.Millisecond)
+	assert.Equal(t, 300*time.Millisecond, r.Delay())
+	r.Cancel()
+
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background()) }()
+	waitTimers(c, 1)
+	c.Advance(300 * time.Millisecond)
+	assert.Equal(t, nil, <-done)
+}
+
+func TestManualClockWaitTimeout(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	var wg WaitGroup
+	wg.Add(1)
+	done := make(chan bool)
+	go func() { done <- wg.WaitTimeoutWithClock(c, time.Hour) }()
+	waitTimers(c, 1)
+	c.Advance(time.Hour)
+	assert.Equal(t, false, <-done)
+
+	go func() { done <- wg.WaitTimeoutWithClock(c, time.Hour) }()
+	waitTimers(c, 1)
+	wg.Done()
+	assert.Equal(t, true, <-done)
+}
@@ -0,0 +1,160 @@
+package sync
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Coalescer collects submitted items into batches and passes each batch to a flush function, once the batch reaches
+// the size threshold or the oldest item in it has waited for the max delay, whichever happens first, e.g. to batch
+// writes to a database. Close() flushes the last batch, exactly once as it's enforced by a Once.
+// Clients should use NewCoalescer to create objects.
+type Coalescer[T any] struct {
+	clock    Clock
+	size     int
+	maxDelay time.Duration
+	flush    func([]T)
+	flushMu  sync.Mutex // serializes the calls of flush
+	o        *Once
+
+	mu     sync.Mutex
+	batch  []T    // guarded by mu
+	queue  [][]T  // batches taken but not flushed yet, oldest first. guarded by mu
+	timer  Timer  // running while batch isn't empty. guarded by mu
+	gen    uint64 // bumped when a batch is taken, so that its timer doesn't take the next one. guarded by mu
+	closed bool   // guarded by mu
+}
+
+// NewCoalescer returns a Coalescer calling flush with batches of up to size items, at most maxDelay after the first
+// item of the batch is submitted. flush is called from the goroutine completing the batch, from a timer goroutine
+// or from the caller of Flush() or Close(), but never concurrently with itself. An error is returned if size or
+// maxDelay isn't positive or flush is nil.
+func NewCoalescer[T any](size int, maxDelay time.Duration, flush func([]T)) (*Coalescer[T], error) {
+	return NewCoalescerWithClock(RealClock{}, size, maxDelay, flush)
+}
+
+// NewCoalescerWithClock is same as NewCoalescer but the max delay is measured by clock.
+func NewCoalescerWithClock[T any](clock Clock, size int, maxDelay time.Duration, flush func([]T)) (*Coalescer[T], error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("size needs to be positive, got %d", size)
+	}
+	if maxDelay <= 0 {
+		return nil, fmt.Errorf("max delay needs to be positive, got %v", maxDelay)
+	}
+	if flush == nil {
+		return nil, fmt.Errorf("flush function can't be nil")
+	}
+	c := &Coalescer[T]{clock: clock, size: size, maxDelay: maxDelay, flush: flush}
+	// lazyDone = true, so the Coalescer becomes DONE only after the last batch is flushed
+	c.o, _ = NewOnce(true, false, VerifyNone, func() bool {
+		c.mu.Lock()
+		c.closed = true
+		c.queueBatch()
+		c.mu.Unlock()
+		// flushes the batches queued by others too, so none is still being flushed when Close() returns
+		c.drain()
+		return true
+	})
+	return c, nil
+}
+
+// Submit adds item to the current batch, flushing the batch in the calling goroutine if it reaches the size
+// threshold. It returns ErrClosed if the Coalescer is closed, in which case item is dropped.
+func (c *Coalescer[T]) Submit(item T) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.batch = append(c.batch, item)
+	if len(c.batch) >= c.size {
+		c.queueBatch()
+		c.mu.Unlock()
+		c.drain()
+		return nil
+	}
+	if len(c.batch) == 1 {
+		gen := c.gen
+		c.timer = c.clock.AfterFunc(c.maxDelay, func() { c.expire(gen) })
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// Flush flushes the current batch right away in the calling goroutine, if it isn't empty, and returns the number of
+// items flushed.
+func (c *Coalescer[T]) Flush() int {
+	c.mu.Lock()
+	n := c.queueBatch()
+	c.mu.Unlock()
+	c.drain()
+	return n
+}
+
+// Close flushes the last batch and makes later submits fail. Only the first call flushes, the others block till
+// it's done.
+func (c *Coalescer[T]) Close() {
+	c.o.Do()
+	c.o.Done(true)
+}
+
+// Pending returns the number of items in the current batch.
+func (c *Coalescer[T]) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.batch)
+}
+
+// expire is called by the timer of the batch of generation gen once it has waited for the max delay.
+func (c *Coalescer[T]) expire(gen uint64) {
+	c.mu.Lock()
+	if gen != c.gen {
+		c.mu.Unlock()
+		return
+	}
+	c.queueBatch()
+	c.mu.Unlock()
+	c.drain()
+}
+
+// take returns the current batch and starts a new one. Must be called holding mu.
+func (c *Coalescer[T]) take() []T {
+	batch := c.batch
+	c.batch = nil
+	c.gen++
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	return batch
+}
+
+// queueBatch takes the current batch and queues it for drain(), unless it's empty. It returns the number of items
+// queued. Must be called holding mu. Batches are queued in the order they're taken, so they're flushed in that
+// order whichever goroutine drains them.
+func (c *Coalescer[T]) queueBatch() int {
+	batch := c.take()
+	if len(batch) > 0 {
+		c.queue = append(c.queue, batch)
+	}
+	return len(batch)
+}
+
+// drain calls flush with the queued batches, oldest first. Batches queued while it runs are flushed too.
+func (c *Coalescer[T]) drain() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+	for {
+		c.mu.Lock()
+		if len(c.queue) == 0 {
+			c.mu.Unlock()
+			return
+		}
+		batch := c.queue[0]
+		c.queue[0] = nil
+		c.queue = c.queue[1:]
+		c.mu.Unlock()
+		c.flush(batch)
+	}
+}
@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// batchRecorder records the batches flushed by a Coalescer.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *batchRecorder) flush(batch []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+}
+
+func (r *batchRecorder) get() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]int(nil), r.batches...)
+}
+
+func TestNewCoalescer(t *testing.T) {
+	_, err := NewCoalescer[int](0, time.Second, func([]int) {})
+	assert.NotEqual(t, err, nil)
+	_, err = NewCoalescer[int](1, 0, func([]int) {})
+	assert.NotEqual(t, err, nil)
+	_, err = NewCoalescer[int](1, time.Second, nil)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestCoalescerSize(t *testing.T) {
+	var r batchRecorder
+	c, _ := NewCoalescer(3, time.Hour, r.flush)
+	for i := 1; i <= 7; i++ {
+		assert.Equal(t, nil, c.Submit(i))
+	}
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, r.get())
+	assert.Equal(t, 1, c.Pending())
+
+	assert.Equal(t, 1, c.Flush())
+	assert.Equal(t, 0, c.Flush())
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}, r.get())
+}
+
+func TestCoalescerMaxDelay(t *testing.T) {
+	var r batchRecorder
+	clock := NewManualClock(clockEpoch)
+	c, _ := NewCoalescerWithClock(clock, 100, 10*time.Millisecond, r.flush)
+	c.Submit(1)
+	clock.Advance(5 * time.Millisecond)
+	c.Submit(2)
+	clock.Advance(5*time.Millisecond - time.Nanosecond)
+	assert.Equal(t, 0, len(r.get()))
+	// measured from the first item of the batch
+	clock.Advance(time.Nanosecond)
+	assert.Equal(t, [][]int{{1, 2}}, r.get())
+
+	c.Submit(3)
+	c.Close()
+	c.Close()
+	assert.Equal(t, [][]int{{1, 2}, {3}}, r.get())
+	assert.Equal(t, ErrClosed, c.Submit(4))
+	assert.Equal(t, 0, clock.Timers())
+	clock.Advance(time.Hour)
+	assert.Equal(t, [][]int{{1, 2}, {3}}, r.get())
+}
+
+func TestCoalescerConcurrent(t *testing.T) {
+	var r batchRecorder
+	c, _ := NewCoalescer(7, time.Millisecond, r.flush)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Submit(i)
+			}
+		}()
+	}
+	wg.Wait()
+	c.Close()
+	n := 0
+	for _, b := range r.get() {
+		assert.LessOrEqual(t, len(b), 7)
+		n += len(b)
+	}
+	assert.Equal(t, 800, n)
+}
+
+func TestCoalescerOrder(t *testing.T) {
+	var r batchRecorder
+	var inFlight int32
+	c, _ := NewCoalescer(5, time.Microsecond, func(batch []int) {
+		assert.Equal(t, int32(1), atomic.AddInt32(&inFlight, 1))
+		r.flush(batch)
+		atomic.AddInt32(&inFlight, -1)
+	})
+	stop := make(chan struct{})
+	var flushers sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		flushers.Add(1)
+		go func() {
+			defer flushers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					c.Flush()
+				}
+			}
+		}()
+	}
+	// size, timer and Flush() triggered batches race, but they're flushed in submission order
+	for i := 0; i < 1000; i++ {
+		c.Submit(i)
+	}
+	close(stop)
+	flushers.Wait()
+	c.Close()
+
+	var items []int
+	for _, b := range r.get() {
+		items = append(items, b...)
+	}
+	assert.Equal(t, 1000, len(items))
+	for i, v := range items {
+		if v != i {
+			t.Fatalf("item %d flushed at position %d", v, i)
+		}
+	}
+}
+
+func TestCoalescerCloseWaitsFlush(t *testing.T) {
+	release := make(chan struct{})
+	flushing := make(chan struct{})
+	var r batchRecorder
+	c, _ := NewCoalescer(2, time.Hour, func(batch []int) {
+		if batch[0] == 0 {
+			close(flushing)
+			<-release
+		}
+		r.flush(batch)
+	})
+	go func() {
+		c.Submit(0)
+		c.Submit(1) // flushes the batch, blocking
+	}()
+	<-flushing
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a batch was being flushed")
+	default:
+	}
+	close(release)
+	<-closed
+	assert.Equal(t, [][]int{{0, 1}}, r.get())
+}
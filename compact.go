@@ -0,0 +1,147 @@
+package sync
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	compactDone   uint32 = 1 << iota // DONE, set before the function/s are executed
+	compactClosed                    // Close() was called
+)
+
+// compactWoken is stored in CompactOnce.ch once the waiters have been woken up. It's never dereferenced.
+var compactWoken = unsafe.Pointer(new(chan struct{}))
+
+// CompactOnce is a lightweight Once for programs which create a large number of them, e.g. one per cache key.
+// It has no mutex or Cond. The state is a single atomic word, and a channel is allocated only when some goroutine
+// actually blocks on Done(true).
+//
+// CompactOnce behaves like a Once created with NewDefaultOnce i.e. the state is set to DONE before the function/s
+// are called and panics are not suppressed. So a goroutine calling Do() while the function/s are executing
+// returns false right away, same as it would with such a Once.
+// There is no Reset. A CompactOnce must not be copied after first use, same as a Once.
+type CompactOnce struct {
+	noCopy  noCopy
+	checker copyChecker
+	state   uint32
+	ch      unsafe.Pointer // *chan struct{} closed to wake up the waiters, or compactWoken
+	fs      []FuncType
+}
+
+// NewOnceCompact returns a CompactOnce which executes f and fs in order.
+// Same as NewOnce, an error is returned if any of the functions is nil.
+func NewOnceCompact(f FuncType, fs ...FuncType) (*CompactOnce, error) {
+	fs = append([]FuncType{f}, fs...)
+	for i, f := range fs {
+		if f == nil {
+			return nil, fmt.Errorf("function at index %d is nil", i)
+		}
+	}
+	return &CompactOnce{fs: fs}, nil
+}
+
+// Do executes the function/s if they haven't been executed and the CompactOnce isn't closed.
+// It returns true only for the caller which executed them.
+func (d *CompactOnce) Do() bool {
+	d.checker.check("CompactOnce")
+	if !atomic.CompareAndSwapUint32(&d.state, 0, compactDone) {
+		return false
+	}
+	defer d.wake()
+
+	for _, f := range d.fs {
+		f()
+	}
+	return true
+}
+
+// TryDo is same as Do() since Do() of a CompactOnce never blocks.
+func (d *CompactOnce) TryDo() bool {
+	return d.Do()
+}
+
+// Done returns if the CompactOnce is in DONE state. If block = true, it blocks till the CompactOnce is DONE or closed.
+func (d *CompactOnce) Done(block bool) bool {
+	d.checker.check("CompactOnce")
+	if block && atomic.LoadUint32(&d.state) == 0 {
+		d.wait()
+	}
+	return atomic.LoadUint32(&d.state)&compactDone != 0
+}
+
+// Wait blocks same as Done(true).
+func (d *CompactOnce) Wait() {
+	d.Done(true)
+}
+
+// DoneChan returns a channel which is closed when the CompactOnce is DONE or closed. The channel is allocated only
+// if the CompactOnce is neither yet.
+func (d *CompactOnce) DoneChan() <-chan struct{} {
+	for atomic.LoadUint32(&d.state) == 0 {
+		p := atomic.LoadPointer(&d.ch)
+		if p == compactWoken {
+			break
+		}
+		if p == nil {
+			ch := make(chan struct{})
+			if !atomic.CompareAndSwapPointer(&d.ch, nil, unsafe.Pointer(&ch)) {
+				continue
+			}
+			p = unsafe.Pointer(&ch)
+		}
+		// same as in wait(), the state is checked again once the channel is in place
+		if atomic.LoadUint32(&d.state) != 0 {
+			break
+		}
+		return *(*chan struct{})(p)
+	}
+	return closedCh
+}
+
+// Close unblocks all goroutines blocked on Done(true). Do() is a no-op after Close.
+func (d *CompactOnce) Close() {
+	for {
+		state := atomic.LoadUint32(&d.state)
+		if state&compactClosed != 0 {
+			return
+		}
+		if atomic.CompareAndSwapUint32(&d.state, state, state|compactClosed) {
+			break
+		}
+	}
+	d.wake()
+}
+
+// wait blocks till the state changes from 0.
+func (d *CompactOnce) wait() {
+	for {
+		p := atomic.LoadPointer(&d.ch)
+		if p == compactWoken {
+			return
+		}
+		if p == nil {
+			ch := make(chan struct{})
+			if !atomic.CompareAndSwapPointer(&d.ch, nil, unsafe.Pointer(&ch)) {
+				continue
+			}
+			p = unsafe.Pointer(&ch)
+		}
+
+		// the state is changed before waking up, so checking it after the channel is in place can't miss a wake up
+		if atomic.LoadUint32(&d.state) != 0 {
+			return
+		}
+		<-*(*chan struct{})(p)
+		return
+	}
+}
+
+// wake wakes up the goroutines blocked in wait(). Must be called after changing the state.
+func (d *CompactOnce) wake() {
+	p := atomic.SwapPointer(&d.ch, compactWoken)
+	if p != nil && p != compactWoken {
+		close(*(*chan struct{})(p))
+	}
+}
@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactOnce(t *testing.T) {
+	var (
+		err   error
+		o     *CompactOnce
+		calls []int
+	)
+
+	_, err = NewOnceCompact(nil)
+	assert.NotEqual(t, err, nil)
+	_, err = NewOnceCompact(returnTrue, nil)
+	assert.NotEqual(t, err, nil)
+
+	o, err = NewOnceCompact(func() bool {
+		calls = append(calls, 1)
+		return false
+	}, func() bool {
+		calls = append(calls, 2)
+		return true
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.TryDo())
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, []int{1, 2}, calls)
+}
+
+func TestCompactOnceConcurrent(t *testing.T) {
+	const n = 16
+	var (
+		calls int
+		wins  int32
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+	)
+
+	o, err := NewOnceCompact(func() bool {
+		calls++
+		return true
+	})
+	assert.Equal(t, err, nil)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if o.Do() {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, int32(1), wins)
+}
+
+func TestCompactOnceBlockingDone(t *testing.T) {
+	const n = 8
+	var wg sync.WaitGroup
+
+	o, err := NewOnceCompact(returnTrue)
+	assert.Equal(t, err, nil)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, true, o.Done(true))
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, true, o.Do())
+	wg.Wait()
+	assert.Equal(t, true, o.Done(true))
+}
+
+func TestCompactOnceClose(t *testing.T) {
+	const n = 8
+	var wg sync.WaitGroup
+
+	o, err := NewOnceCompact(returnTrue)
+	assert.Equal(t, err, nil)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, false, o.Done(true))
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	o.Close()
+	o.Close()
+	wg.Wait()
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.Done(true))
+}
+
+func TestCompactOncePanic(t *testing.T) {
+	o, err := NewOnceCompact(doPanic)
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { o.Do() })
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, false, o.Do())
+}
+
+func BenchmarkNewOnce(b *testing.B) {
+	b.ReportAllocs()
+	b.ReportMetric(float64(unsafe.Sizeof(Once{})), "struct-bytes")
+	for i := 0; i < b.N; i++ {
+		o, _ := NewDefaultOnce(returnTrue)
+		o.Do()
+	}
+}
+
+func BenchmarkNewOnceCompact(b *testing.B) {
+	b.ReportAllocs()
+	b.ReportMetric(float64(unsafe.Sizeof(CompactOnce{})), "struct-bytes")
+	for i := 0; i < b.N; i++ {
+		o, _ := NewOnceCompact(returnTrue)
+		o.Do()
+	}
+}
@@ -0,0 +1,56 @@
+package sync
+
+import "sync/atomic"
+
+// DoFunc is same as DoAlso() with f as the extra function, for callers holding a function with the signature used
+// by the Once of golang's sync package. The function/s of the Once are executed first, then f.
+// Only the f of the caller which executes is called, same as with sync.Once.
+func (d *Once) DoFunc(f func()) bool {
+	return d.DoAlso(func() bool {
+		f()
+		return true
+	})
+}
+
+// StdOnce adapts a Once to the method set of the Once of golang's sync package, so that it can be passed to code
+// written against interface{ Do(func()) }. The embedded Once stays accessible for Done, Close, Reset etc.
+// Clients should use NewStdOnce or Once.Compat to create objects.
+type StdOnce struct {
+	*Once
+}
+
+// StdDoer is implemented by the Once of golang's sync package and by StdOnce.
+type StdDoer interface {
+	Do(f func())
+}
+
+// NewStdOnce returns a StdOnce whose Once has no function/s of its own, so Do(f) executes only f.
+// The Once is created with WithLazyDone(true) and WithStickyFailure(true) so that, same as sync.Once, it becomes
+// DONE only when f returns, even if f panics. opts are applied after them, same as for NewOnceWithOptions.
+func NewStdOnce(opts ...Option) (*StdOnce, error) {
+	opts = append([]Option{WithLazyDone(true), WithStickyFailure(true)}, opts...)
+	o, err := NewOnceWithOptions([]FuncType{func() bool { return true }}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return o.Compat(), nil
+}
+
+// Compat returns a StdOnce backed by d. Do(f) of the StdOnce executes the function/s of d followed by f.
+func (d *Once) Compat() *StdOnce {
+	return &StdOnce{d}
+}
+
+// Do calls DoFunc(f) of the underlying Once, discarding the result like sync.Once does.
+// Same as DoOrWait, no call returns before the execution in progress has completed, even if the Once has
+// lazyDone = false, so callers observe the side effects of f like they would with sync.Once.
+func (s *StdOnce) Do(f func()) {
+	// fast path: same as the one of Do(), without wrapping f
+	if s.Once.fastDone() && s.Once.executed() {
+		atomic.AddUint64(&s.calls, 1)
+		return
+	}
+	if !s.Once.DoFunc(f) {
+		s.Once.waitExecution()
+	}
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdOnce(t *testing.T) {
+	o, err := NewStdOnce()
+	assert.Equal(t, err, nil)
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Do(func() { atomic.AddInt32(&calls, 1) })
+			// same as sync.Once, Do returns after the execution finished
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, true, o.Done(false))
+
+	o.Reset()
+	o.Do(func() { atomic.AddInt32(&calls, 1) })
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestStdOncePanic(t *testing.T) {
+	var d StdDoer
+	o, err := NewStdOnce()
+	assert.Equal(t, err, nil)
+	d = o
+
+	assert.Panics(t, func() { d.Do(func() { panic("boom") }) })
+	called := false
+	d.Do(func() { called = true })
+	assert.Equal(t, false, called)
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestOnceDoFunc(t *testing.T) {
+	var order []string
+	o, err := NewDefaultOnce(func() bool {
+		order = append(order, "once")
+		return true
+	})
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, true, o.DoFunc(func() { order = append(order, "f") }))
+	assert.Equal(t, false, o.DoFunc(func() { order = append(order, "g") }))
+	assert.Equal(t, []string{"once", "f"}, order)
+
+	o.Reset()
+	o.Compat().Do(func() { order = append(order, "h") })
+	assert.Equal(t, []string{"once", "f", "once", "h"}, order)
+}
+
+func TestStdOnceLoserWaits(t *testing.T) {
+	std, err := NewStdOnce()
+	assert.Equal(t, err, nil)
+	def, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+
+	for _, o := range []*StdOnce{std, def.Compat()} {
+		var finished int32
+		started := make(chan struct{})
+		go o.Do(func() {
+			close(started)
+			time.Sleep(time.Millisecond * 5)
+			atomic.StoreInt32(&finished, 1)
+		})
+		<-started
+
+		// same as sync.Once, no call to Do returns before f has returned
+		o.Do(func() {})
+		assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+	}
+}
+
+func TestStdOnceFastPath(t *testing.T) {
+	s, err := NewStdOnce()
+	assert.Equal(t, err, nil)
+	s.Do(func() {})
+
+	// once DONE, neither Do nor DoOrWait take the lock, held here as if by a long Reset()
+	s.Once.mu.Lock()
+	defer s.Once.mu.Unlock()
+	calls := 0
+	s.Do(func() { calls++ })
+	assert.Equal(t, false, s.DoOrWait())
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, uint64(3), s.Stats().Calls)
+}
@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cond is a condition variable like the Cond of golang's sync package, whose waits can be bounded by a timeout
+// or a context. It's built on channels, one per waiter, so a waiter can select on other channels too.
+// Same as there, L is held while checking the condition and calling the Wait methods.
+// Clients should use NewCond to create objects. A Cond must not be copied after first use.
+type Cond struct {
+	L sync.Locker
+
+	mu      sync.Mutex
+	waiters list.List // *condWaiter in arrival order. guarded by mu
+}
+
+type condWaiter struct {
+	ch       chan struct{} // closed to wake up the waiter
+	signaled bool          // guarded by Cond.mu
+}
+
+// NewCond returns a Cond using l.
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait unlocks L, blocks till woken up by Signal() or Broadcast(), and locks L again before returning.
+// Same as with sync.Cond, the condition must be checked again after Wait returns, see WaitFor.
+func (c *Cond) Wait() {
+	c.WaitCtx(context.Background())
+}
+
+// WaitTimeout is same as Wait() but gives up after timeout. It returns true if it was woken up.
+// L is locked again before returning in either case.
+func (c *Cond) WaitTimeout(timeout time.Duration) bool {
+	return c.WaitTimeoutWithClock(RealClock{}, timeout)
+}
+
+// WaitTimeoutWithClock is same as WaitTimeout() but the timeout is measured by clock.
+func (c *Cond) WaitTimeoutWithClock(clock Clock, timeout time.Duration) bool {
+	ctx, cancel := timeoutContext(clock, timeout)
+	defer cancel()
+	return c.WaitCtx(ctx) == nil
+}
+
+// WaitCtx is same as Wait() but gives up when ctx is done, in which case it returns ctx.Err().
+// A waiter woken up right when ctx gets done returns nil, so that no Signal() is lost.
+// L is locked again before returning in either case.
+func (c *Cond) WaitCtx(ctx context.Context) error {
+	w := &condWaiter{ch: make(chan struct{})}
+	c.mu.Lock()
+	elem := c.waiters.PushBack(w)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if w.signaled {
+			return nil
+		}
+		c.waiters.Remove(elem)
+		return ctx.Err()
+	}
+}
+
+// WaitFor calls Wait() till pred returns true. pred is called with L locked, and L is locked when WaitFor returns.
+func (c *Cond) WaitFor(pred func() bool) {
+	for !pred() {
+		c.Wait()
+	}
+}
+
+// Signal wakes up the goroutine which has been waiting the longest, if any.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if front := c.waiters.Front(); front != nil {
+		c.wake(c.waiters.Remove(front).(*condWaiter))
+	}
+}
+
+// Broadcast wakes up all waiting goroutines.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		c.wake(e.Value.(*condWaiter))
+	}
+	c.waiters.Init()
+}
+
+// wake wakes up w. Must be called holding mu.
+func (c *Cond) wake(w *condWaiter) {
+	w.signaled = true
+	close(w.ch)
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+
+	const n = 5
+	ready := 0
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			c.WaitFor(func() bool { return ready > 0 })
+			ready--
+			mu.Unlock()
+		}()
+	}
+
+	// each Signal releases one waiter
+	for i := 0; i < n; i++ {
+		mu.Lock()
+		ready++
+		c.Signal()
+		mu.Unlock()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, ready)
+}
+
+func TestCondBroadcast(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+
+	const n = 5
+	done := false
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			mu.Lock()
+			c.WaitFor(func() bool { return done })
+			mu.Unlock()
+		}()
+	}
+	for {
+		c.mu.Lock()
+		waiting := c.waiters.Len()
+		c.mu.Unlock()
+		if waiting == n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	done = true
+	c.Broadcast()
+	mu.Unlock()
+	wg.Wait()
+}
+
+func TestCondBounded(t *testing.T) {
+	var mu sync.Mutex
+	c := NewCond(&mu)
+
+	clock := NewManualClock(clockEpoch)
+	mu.Lock()
+	assert.Equal(t, false, advanceWhile(clock, time.Hour, func() bool { return c.WaitTimeoutWithClock(clock, time.Hour) }))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, c.WaitCtx(ctx))
+	// L is held again after giving up
+	assert.Equal(t, false, mu.TryLock())
+	mu.Unlock()
+
+	// waiters which gave up are not signaled anymore
+	c.mu.Lock()
+	assert.Equal(t, 0, c.waiters.Len())
+	c.mu.Unlock()
+
+	woken := make(chan bool)
+	go func() {
+		mu.Lock()
+		defer mu.Unlock()
+		woken <- c.WaitTimeoutWithClock(clock, time.Hour)
+	}()
+	for {
+		c.mu.Lock()
+		n := c.waiters.Len()
+		c.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Signal()
+	assert.Equal(t, true, <-woken)
+}
@@ -0,0 +1,39 @@
+package sync
+
+import "context"
+
+// contextGroup deduplicates calls to DoForContext by the context value.
+var contextGroup Group
+
+// contextGroupKey is the key in contextGroup for the value of key in a context.
+// key is part of it so that different context keys with equal values don't share a Once.
+type contextGroupKey struct {
+	key   interface{}
+	value interface{}
+}
+
+// DoForContext executes f once per distinct value of ctx.Value(key), across all goroutines.
+// It's useful to run an expensive operation once per logical request, e.g. per correlation ID carried by the context.
+// Same as Group.Do, concurrent callers for the same value stay blocked till f finishes and only the f of the first
+// caller is used. If ctx has no value for key, there is nothing to deduplicate on and f is simply executed.
+//
+// The Once of a value is kept till ForgetForContext is called for it, so callers should forget values which
+// won't be seen again to not grow the memory indefinitely.
+func DoForContext(ctx context.Context, key interface{}, f FuncType) (bool, error) {
+	value := ctx.Value(key)
+	if value == nil {
+		o, err := NewDefaultOnce(f)
+		if err != nil {
+			return false, err
+		}
+		return o.Do(), nil
+	}
+	return contextGroup.Do(contextGroupKey{key: key, value: value}, f)
+}
+
+// ForgetForContext forgets the value of ctx.Value(key) so that a later DoForContext for it executes again.
+func ForgetForContext(ctx context.Context, key interface{}) {
+	if value := ctx.Value(key); value != nil {
+		contextGroup.Forget(contextGroupKey{key: key, value: value})
+	}
+}
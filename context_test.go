@@ -0,0 +1,71 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey string
+
+func TestDoForContext(t *testing.T) {
+	const key = ctxKey("request-id")
+	var calls sync.Map
+	f := func(id string) FuncType {
+		return func() bool {
+			n, _ := calls.LoadOrStore(id, new(int32))
+			atomic.AddInt32(n.(*int32), 1)
+			return true
+		}
+	}
+
+	var wg sync.WaitGroup
+	var winners int32
+	for i := 0; i < 20; i++ {
+		id := []string{"a", "b"}[i%2]
+		ctx := context.WithValue(context.Background(), key, id)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := DoForContext(ctx, key, f(id))
+			assert.Equal(t, err, nil)
+			if res {
+				atomic.AddInt32(&winners, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), winners)
+	for _, id := range []string{"a", "b"} {
+		n, _ := calls.Load(id)
+		assert.Equal(t, int32(1), atomic.LoadInt32(n.(*int32)))
+	}
+
+	// forgetting a value executes again
+	ctx := context.WithValue(context.Background(), key, "a")
+	res, err := DoForContext(ctx, key, f("a"))
+	assert.Equal(t, false, res)
+	assert.Equal(t, err, nil)
+	ForgetForContext(ctx, key)
+	res, err = DoForContext(ctx, key, f("a"))
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+	ForgetForContext(ctx, key)
+	ForgetForContext(context.WithValue(context.Background(), key, "b"), key)
+
+	// no value: always executed
+	executed := 0
+	g := func() bool { executed++; return true }
+	DoForContext(context.Background(), key, g)
+	DoForContext(context.Background(), key, g)
+	assert.Equal(t, 2, executed)
+
+	// same value under a different key is independent
+	res, err = DoForContext(context.WithValue(context.Background(), ctxKey("other"), "a"), ctxKey("other"), returnTrue)
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+	ForgetForContext(context.WithValue(context.Background(), ctxKey("other"), "a"), ctxKey("other"))
+}
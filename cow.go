@@ -0,0 +1,43 @@
+package sync
+
+import "sync"
+
+// CopyOnWrite holds read mostly data, e.g. a config or a routing table: readers Load() a snapshot without any
+// locking, so reads scale with the number of cores, and writers Update() it by building a modified copy which
+// replaces the snapshot atomically. Writers are serialized, so each update sees the previous one.
+// A snapshot must be treated as immutable: modify a copy in Update instead.
+// The zero value holds the zero value of T. A CopyOnWrite must not be copied after first use.
+type CopyOnWrite[T any] struct {
+	v  Value[T]
+	mu sync.Mutex // serializes the writers
+}
+
+// NewCopyOnWrite returns a CopyOnWrite holding v.
+func NewCopyOnWrite[T any](v T) *CopyOnWrite[T] {
+	c := &CopyOnWrite[T]{}
+	c.v.Store(v)
+	return c
+}
+
+// Load returns the current snapshot. It never blocks.
+func (c *CopyOnWrite[T]) Load() T {
+	return c.v.Load()
+}
+
+// Store replaces the snapshot with v.
+func (c *CopyOnWrite[T]) Store(v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v.Store(v)
+}
+
+// Update replaces the snapshot with the one returned by f, which gets the current snapshot and must return
+// a modified copy of it rather than modify it in place. f is called exactly once, holding the writers' lock,
+// so it must not call Update or Store. Update returns the new snapshot.
+func (c *CopyOnWrite[T]) Update(f func(T) T) T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v := f(c.v.Load())
+	c.v.Store(v)
+	return v
+}
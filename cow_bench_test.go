@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"maps"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// benchmarkRoutes returns a routing table of n routes.
+func benchmarkRoutes(n int) map[string]string {
+	routes := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		routes["/path/"+strconv.Itoa(i)] = "backend-" + strconv.Itoa(i%8)
+	}
+	return routes
+}
+
+// BenchmarkRoutingTable compares lookups in a routing table held by a CopyOnWrite with one guarded by an RWMutex,
+// with one in writeEvery operations replacing a route.
+func BenchmarkRoutingTable(b *testing.B) {
+	for _, writeEvery := range []int{0, 1000} {
+		name := "ReadOnly"
+		if writeEvery > 0 {
+			name = "Write1In" + strconv.Itoa(writeEvery)
+		}
+		b.Run(name+"/CopyOnWrite", func(b *testing.B) {
+			c := NewCopyOnWrite(benchmarkRoutes(64))
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if writeEvery > 0 && i%writeEvery == 0 {
+						c.Update(func(m map[string]string) map[string]string {
+							m = maps.Clone(m)
+							m["/path/0"] = "backend-0"
+							return m
+						})
+					} else {
+						_ = c.Load()["/path/"+strconv.Itoa(i%64)]
+					}
+					i++
+				}
+			})
+		})
+		b.Run(name+"/RWMutex", func(b *testing.B) {
+			var mu sync.RWMutex
+			routes := benchmarkRoutes(64)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if writeEvery > 0 && i%writeEvery == 0 {
+						mu.Lock()
+						routes["/path/0"] = "backend-0"
+						mu.Unlock()
+					} else {
+						mu.RLock()
+						_ = routes["/path/"+strconv.Itoa(i%64)]
+						mu.RUnlock()
+					}
+					i++
+				}
+			})
+		})
+	}
+}
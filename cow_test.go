@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	var zero CopyOnWrite[[]int]
+	assert.Equal(t, []int(nil), zero.Load())
+
+	c := NewCopyOnWrite(map[string]int{"a": 1})
+	before := c.Load()
+	after := c.Update(func(m map[string]int) map[string]int {
+		m = maps.Clone(m)
+		m["b"] = 2
+		return m
+	})
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, after)
+	assert.Equal(t, after, c.Load())
+	// the old snapshot is untouched
+	assert.Equal(t, map[string]int{"a": 1}, before)
+
+	c.Store(nil)
+	assert.Equal(t, map[string]int(nil), c.Load())
+}
+
+func TestCopyOnWriteConcurrent(t *testing.T) {
+	c := NewCopyOnWrite([]int{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				c.Update(func(s []int) []int { return append(s[:len(s):len(s)], j) })
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = len(c.Load())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 400, len(c.Load()))
+}
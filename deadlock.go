@@ -0,0 +1,246 @@
+package sync
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DeadlockOptions configure the deadlock detection of Mutex, RWMutex and KeyedMutex. See EnableDeadlockDetection.
+type DeadlockOptions struct {
+	WaitTimeout time.Duration       // report a goroutine waiting for a lock longer than this. 0 disables the check
+	HoldTimeout time.Duration       // report a lock held longer than this, checked when it's released. 0 disables the check
+	OnDeadlock  func(report string) // called with the report instead of panicking with it
+}
+
+// deadlocks is the state of the deadlock detection. Its fields other than enabled are guarded by mu.
+var deadlocks struct {
+	enabled uint32
+	mu      sync.Mutex
+	opts    DeadlockOptions
+	holders map[any]map[int64]*lockHold // goroutines holding each lock
+	waiting map[int64]any               // lock each goroutine is blocked on
+	names   map[any]string              // names of locks, e.g. KeyedMutex keys
+}
+
+type lockHold struct {
+	count int // read locks are counted per goroutine
+	since time.Time
+}
+
+// EnableDeadlockDetection makes Mutex, RWMutex and KeyedMutex track which goroutines hold which locks and which lock
+// each blocked goroutine waits for. A goroutine about to block on a lock held, directly or through a chain of other
+// blocked goroutines, by itself is reported as a deadlock, and so are the timeouts of opts. A report describes the
+// problem and has a dump of all the goroutines. It's passed to opts.OnDeadlock if set, else it's raised as a panic,
+// in the blocking goroutine for a cycle and in a timer goroutine for a wait timeout.
+// It's meant for tests: the tracking makes locking a lot slower. Locks held when it's enabled aren't tracked.
+func EnableDeadlockDetection(opts DeadlockOptions) {
+	deadlocks.mu.Lock()
+	defer deadlocks.mu.Unlock()
+	deadlocks.opts = opts
+	deadlocks.holders = make(map[any]map[int64]*lockHold)
+	deadlocks.waiting = make(map[int64]any)
+	deadlocks.names = make(map[any]string)
+	atomic.StoreUint32(&deadlocks.enabled, 1)
+}
+
+// DisableDeadlockDetection stops the deadlock detection and drops its state.
+func DisableDeadlockDetection() {
+	deadlocks.mu.Lock()
+	defer deadlocks.mu.Unlock()
+	atomic.StoreUint32(&deadlocks.enabled, 0)
+	deadlocks.holders, deadlocks.waiting, deadlocks.names = nil, nil, nil
+}
+
+// deadlockDetection reports whether the deadlock detection is enabled. It's the only cost when it isn't.
+func deadlockDetection() bool {
+	return atomic.LoadUint32(&deadlocks.enabled) == 1
+}
+
+// lockWaiting records that the calling goroutine is about to block on lock, reporting a cycle if there is one.
+// The returned func must be called once the goroutine stops waiting, with whether it acquired lock.
+func lockWaiting(lock any) func(acquired bool) {
+	if !deadlockDetection() {
+		return func(acquired bool) {}
+	}
+	g := goid()
+	deadlocks.mu.Lock()
+	if deadlocks.waiting == nil {
+		// disabled meanwhile
+		deadlocks.mu.Unlock()
+		return func(acquired bool) {}
+	}
+	deadlocks.waiting[g] = lock
+	cycle := findLockCycle(g, lock)
+	opts := deadlocks.opts
+	deadlocks.mu.Unlock()
+
+	var timer *time.Timer
+	if opts.WaitTimeout > 0 {
+		timer = time.AfterFunc(opts.WaitTimeout, func() {
+			deadlocks.mu.Lock()
+			still := deadlocks.waiting != nil && deadlocks.waiting[g] == lock
+			var msg string
+			if still {
+				msg = fmt.Sprintf("goroutine %d waits for %s for more than %v", g, lockName(lock), opts.WaitTimeout)
+			}
+			deadlocks.mu.Unlock()
+			if still {
+				reportDeadlock(opts, msg)
+			}
+		})
+	}
+	if cycle != "" {
+		reportDeadlock(opts, cycle)
+	}
+	return func(acquired bool) {
+		if timer != nil {
+			timer.Stop()
+		}
+		deadlocks.mu.Lock()
+		if deadlocks.waiting != nil {
+			delete(deadlocks.waiting, g)
+		}
+		deadlocks.mu.Unlock()
+		if acquired {
+			lockAcquired(lock)
+		}
+	}
+}
+
+// lockAcquired records that the calling goroutine holds lock.
+func lockAcquired(lock any) {
+	if !deadlockDetection() {
+		return
+	}
+	g := goid()
+	deadlocks.mu.Lock()
+	defer deadlocks.mu.Unlock()
+	if deadlocks.holders == nil {
+		return
+	}
+	hs := deadlocks.holders[lock]
+	if hs == nil {
+		hs = make(map[int64]*lockHold)
+		deadlocks.holders[lock] = hs
+	}
+	if h := hs[g]; h != nil {
+		h.count++
+		return
+	}
+	hs[g] = &lockHold{count: 1, since: time.Now()}
+}
+
+// lockReleased records that lock was released, by the calling goroutine if it holds it, else by the goroutine which
+// does, as Mutex and RWMutex can be unlocked by another goroutine. It reports a hold timeout.
+func lockReleased(lock any) {
+	if !deadlockDetection() {
+		return
+	}
+	g := goid()
+	deadlocks.mu.Lock()
+	hs := deadlocks.holders[lock]
+	holder, h := g, hs[g]
+	if h == nil {
+		for holder, h = range hs {
+			break
+		}
+	}
+	if h == nil {
+		// acquired before the detection was enabled
+		deadlocks.mu.Unlock()
+		return
+	}
+	held := time.Since(h.since)
+	if h.count--; h.count == 0 {
+		delete(hs, holder)
+		if len(hs) == 0 {
+			delete(deadlocks.holders, lock)
+		}
+	}
+	opts := deadlocks.opts
+	name := lockName(lock)
+	deadlocks.mu.Unlock()
+
+	if opts.HoldTimeout > 0 && held > opts.HoldTimeout {
+		reportDeadlock(opts, fmt.Sprintf("goroutine %d held %s for %v, more than %v", holder, name, held, opts.HoldTimeout))
+	}
+}
+
+// nameLock names lock in the reports. An empty name forgets it.
+func nameLock(lock any, name string) {
+	deadlocks.mu.Lock()
+	defer deadlocks.mu.Unlock()
+	if deadlocks.names == nil {
+		return
+	}
+	if name == "" {
+		delete(deadlocks.names, lock)
+	} else {
+		deadlocks.names[lock] = name
+	}
+}
+
+// findLockCycle returns the description of a cycle of goroutines waiting for each other starting with g blocking
+// on lock, or "" if there is none. Must be called holding deadlocks.mu.
+func findLockCycle(g int64, lock any) string {
+	visited := map[int64]bool{}
+	var path []string
+	var visit func(l any) bool
+	visit = func(l any) bool {
+		for h := range deadlocks.holders[l] {
+			step := fmt.Sprintf("%s is held by goroutine %d", lockName(l), h)
+			if h == g {
+				path = append(path, step)
+				return true
+			}
+			if visited[h] {
+				continue
+			}
+			visited[h] = true
+			if next, ok := deadlocks.waiting[h]; ok {
+				path = append(path, step+" which waits")
+				if visit(next) {
+					return true
+				}
+				path = path[:len(path)-1]
+			}
+		}
+		return false
+	}
+	if !visit(lock) {
+		return ""
+	}
+	return fmt.Sprintf("goroutine %d waits and %s", g, strings.Join(path, " for "))
+}
+
+// lockName returns the name of lock for the reports. Must be called holding deadlocks.mu.
+func lockName(lock any) string {
+	if name, ok := deadlocks.names[lock]; ok {
+		return name
+	}
+	return fmt.Sprintf("%T %p", lock, lock)
+}
+
+// reportDeadlock passes the report made of msg and a dump of all the goroutines to opts.OnDeadlock, or panics
+// with it.
+func reportDeadlock(opts DeadlockOptions, msg string) {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	report := "sync: deadlock detected: " + msg + "\n\n" + string(buf)
+	if opts.OnDeadlock != nil {
+		opts.OnDeadlock(report)
+		return
+	}
+	panic(report)
+}
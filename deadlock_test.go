@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// detectDeadlocks enables the deadlock detection for the test, with the reports sent on the returned channel.
+func detectDeadlocks(t *testing.T, opts DeadlockOptions) <-chan string {
+	reports := make(chan string, 10)
+	opts.OnDeadlock = func(report string) { reports <- report }
+	EnableDeadlockDetection(opts)
+	t.Cleanup(DisableDeadlockDetection)
+	return reports
+}
+
+func TestDeadlockCycle(t *testing.T) {
+	reports := detectDeadlocks(t, DeadlockOptions{})
+	var a Mutex
+	var b RWMutex
+
+	a.Lock()
+	bLocked := make(chan struct{})
+	bDone := make(chan error)
+	go func() {
+		b.Lock()
+		close(bLocked)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := a.LockCtx(ctx)
+		b.Unlock()
+		bDone <- err
+	}()
+	<-bLocked
+	// the other goroutine may not be waiting for a yet, so there's a cycle when either goroutine blocks
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := b.RLockCtx(ctx)
+	report := <-reports
+	assert.True(t, strings.HasPrefix(report, "sync: deadlock detected: goroutine "), report)
+	assert.Contains(t, report, "*sync.RWMutex")
+	assert.Contains(t, report, "*sync.Mutex")
+	assert.Contains(t, report, "goroutine ") // the dump
+
+	a.Unlock()
+	if err == nil {
+		b.RUnlock()
+	}
+	<-bDone
+	assert.Equal(t, 0, len(reports))
+}
+
+func TestDeadlockSelf(t *testing.T) {
+	reports := detectDeadlocks(t, DeadlockOptions{})
+	var m Mutex
+	m.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.LockCtx(ctx))
+	assert.Contains(t, <-reports, "is held by goroutine")
+	m.Unlock()
+
+	// no report for locks which don't block
+	m.Lock()
+	m.Unlock()
+	var rw RWMutex
+	rw.RLock()
+	rw.RLock()
+	rw.RUnlock()
+	rw.RUnlock()
+	assert.Equal(t, 0, len(reports))
+}
+
+func TestDeadlockKeyedMutex(t *testing.T) {
+	reports := detectDeadlocks(t, DeadlockOptions{})
+	var km KeyedMutex[string]
+	km.Lock("users/1")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, km.RLockCtx(ctx, "users/1"))
+	assert.Contains(t, <-reports, "KeyedMutex key users/1 is held by goroutine")
+	km.Unlock("users/1")
+	assert.Equal(t, 0, km.Len())
+}
+
+func TestDeadlockTimeouts(t *testing.T) {
+	reports := detectDeadlocks(t, DeadlockOptions{WaitTimeout: 10 * time.Millisecond, HoldTimeout: 20 * time.Millisecond})
+	var m Mutex
+	m.Lock()
+	go func() {
+		m.Lock()
+		m.Unlock()
+	}()
+	report := <-reports
+	assert.Contains(t, report, "waits for *sync.Mutex")
+	assert.Contains(t, report, "for more than 10ms")
+
+	time.Sleep(20 * time.Millisecond)
+	m.Unlock()
+	report = <-reports
+	assert.Contains(t, report, "held *sync.Mutex")
+	assert.Contains(t, report, "more than 20ms")
+
+	// the other goroutine holds it briefly
+	m.Lock()
+	m.Unlock()
+	assert.Equal(t, 0, len(reports))
+}
+
+func TestDeadlockPanics(t *testing.T) {
+	EnableDeadlockDetection(DeadlockOptions{})
+	defer DisableDeadlockDetection()
+	var m Mutex
+	m.Lock()
+	defer m.Unlock()
+	defer func() {
+		r, _ := recover().(string)
+		assert.True(t, strings.HasPrefix(r, "sync: deadlock detected: "), r)
+	}()
+	m.Lock()
+}
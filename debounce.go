@@ -0,0 +1,206 @@
+package sync
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer coalesces a burst of calls into one execution of a function, executed once no call has been made
+// for the debounce interval. Clients should use Debounce to create objects.
+type Debouncer struct {
+	clock Clock
+	d     time.Duration
+	f     func()
+	runMu sync.Mutex // serializes executions of f
+
+	mu      sync.Mutex
+	timer   Timer  // guarded by mu
+	gen     uint64 // bumped for each call, so that a superseded timer doesn't execute f. guarded by mu
+	pending bool   // guarded by mu
+	stopped bool   // guarded by mu
+}
+
+// Throttler executes a function at most once per interval: the first call of a burst executes it right away
+// and the calls made before the interval is over are coalesced into one execution at the end of the interval.
+// Clients should use Throttle to create objects.
+type Throttler struct {
+	clock Clock
+	d     time.Duration
+	f     func()
+	runMu sync.Mutex // serializes executions of f
+
+	mu      sync.Mutex
+	last    time.Time // time of the latest execution. guarded by mu
+	timer   Timer     // guarded by mu
+	gen     uint64    // bumped when the pending execution is done or dropped. guarded by mu
+	pending bool      // guarded by mu
+	stopped bool      // guarded by mu
+}
+
+// Debounce returns a Debouncer executing f once d has elapsed since the latest Call(). f is executed in its own
+// goroutine, or in the goroutine calling Flush(), but never concurrently with itself.
+func Debounce(d time.Duration, f func()) *Debouncer {
+	return DebounceWithClock(RealClock{}, d, f)
+}
+
+// DebounceWithClock is same as Debounce but the interval is measured by clock.
+func DebounceWithClock(clock Clock, d time.Duration, f func()) *Debouncer {
+	return &Debouncer{clock: clock, d: d, f: f}
+}
+
+// Call schedules an execution of f after the debounce interval, postponing the one already scheduled if any.
+// It's a no-op after Stop().
+func (db *Debouncer) Call() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.stopped {
+		return
+	}
+	db.pending = true
+	db.gen++
+	if db.timer != nil {
+		db.timer.Stop()
+	}
+	gen := db.gen
+	db.timer = db.clock.AfterFunc(db.d, func() { db.fire(gen) })
+}
+
+// Flush executes the scheduled execution of f right away in the calling goroutine, if any, and returns whether
+// it did.
+func (db *Debouncer) Flush() bool {
+	db.mu.Lock()
+	if !db.pending {
+		db.mu.Unlock()
+		return false
+	}
+	db.cancel()
+	db.mu.Unlock()
+	db.run()
+	return true
+}
+
+// Stop drops the scheduled execution of f, if any, and makes later calls to Call() no-op. It returns whether
+// an execution was dropped.
+func (db *Debouncer) Stop() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stopped = true
+	pending := db.pending
+	db.cancel()
+	return pending
+}
+
+// fire is called by the timer of the call which bumped the generation to gen.
+func (db *Debouncer) fire(gen uint64) {
+	db.mu.Lock()
+	if gen != db.gen || !db.pending {
+		db.mu.Unlock()
+		return
+	}
+	db.pending = false
+	db.mu.Unlock()
+	db.run()
+}
+
+// cancel drops the scheduled execution. Must be called holding mu.
+func (db *Debouncer) cancel() {
+	db.pending = false
+	db.gen++
+	if db.timer != nil {
+		db.timer.Stop()
+	}
+}
+
+func (db *Debouncer) run() {
+	db.runMu.Lock()
+	defer db.runMu.Unlock()
+	db.f()
+}
+
+// Throttle returns a Throttler executing f at most once per interval d. The leading execution of a burst happens
+// in the goroutine calling Call() and the trailing one in its own goroutine, but f is never executed concurrently
+// with itself.
+func Throttle(d time.Duration, f func()) *Throttler {
+	return ThrottleWithClock(RealClock{}, d, f)
+}
+
+// ThrottleWithClock is same as Throttle but the interval is measured by clock.
+func ThrottleWithClock(clock Clock, d time.Duration, f func()) *Throttler {
+	return &Throttler{clock: clock, d: d, f: f}
+}
+
+// Call executes f right away if it wasn't executed in the last interval. Otherwise it schedules an execution at the
+// end of the interval, unless one is scheduled already. It's a no-op after Stop().
+func (th *Throttler) Call() {
+	th.mu.Lock()
+	if th.stopped || th.pending {
+		th.mu.Unlock()
+		return
+	}
+	now := th.clock.Now()
+	if th.last.IsZero() || !now.Before(th.last.Add(th.d)) {
+		th.last = now
+		th.mu.Unlock()
+		th.run()
+		return
+	}
+	th.pending = true
+	gen := th.gen
+	th.timer = th.clock.AfterFunc(th.last.Add(th.d).Sub(now), func() { th.fire(gen) })
+	th.mu.Unlock()
+}
+
+// Flush executes the scheduled execution of f right away in the calling goroutine, if any, and returns whether
+// it did. The interval restarts from the flush.
+func (th *Throttler) Flush() bool {
+	th.mu.Lock()
+	if !th.pending {
+		th.mu.Unlock()
+		return false
+	}
+	th.cancel()
+	th.last = th.clock.Now()
+	th.mu.Unlock()
+	th.run()
+	return true
+}
+
+// Stop drops the scheduled execution of f, if any, and makes later calls to Call() no-op. It returns whether
+// an execution was dropped.
+func (th *Throttler) Stop() bool {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	th.stopped = true
+	pending := th.pending
+	th.cancel()
+	return pending
+}
+
+// fire is called by the timer scheduled in generation gen.
+func (th *Throttler) fire(gen uint64) {
+	th.mu.Lock()
+	if gen != th.gen || !th.pending {
+		th.mu.Unlock()
+		return
+	}
+	th.pending = false
+	th.gen++
+	th.last = th.clock.Now()
+	th.mu.Unlock()
+	th.run()
+}
+
+// cancel drops the scheduled execution. Must be called holding mu.
+func (th *Throttler) cancel() {
+	th.pending = false
+	th.gen++
+	if th.timer != nil {
+		th.timer.Stop()
+	}
+}
+
+func (th *Throttler) run() {
+	th.runMu.Lock()
+	defer th.runMu.Unlock()
+	th.f()
+}
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebounce(t *testing.T) {
+	var n int32
+	c := NewManualClock(clockEpoch)
+	db := DebounceWithClock(c, 20*time.Millisecond, func() { atomic.AddInt32(&n, 1) })
+	for i := 0; i < 5; i++ {
+		db.Call()
+		c.Advance(19 * time.Millisecond)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&n))
+	c.Advance(time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+
+	assert.Equal(t, false, db.Flush())
+	db.Call()
+	assert.Equal(t, true, db.Flush())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+	c.Advance(time.Hour)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+
+	db.Call()
+	assert.Equal(t, true, db.Stop())
+	db.Call()
+	c.Advance(time.Hour)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+}
+
+func TestThrottle(t *testing.T) {
+	var n int32
+	c := NewManualClock(clockEpoch)
+	th := ThrottleWithClock(c, 30*time.Millisecond, func() { atomic.AddInt32(&n, 1) })
+	for i := 0; i < 5; i++ {
+		th.Call()
+	}
+	// leading execution right away, the rest coalesced into a trailing one
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+	c.Advance(29 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+	c.Advance(time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+
+	atomic.StoreInt32(&n, 0)
+	th = ThrottleWithClock(c, time.Hour, func() { atomic.AddInt32(&n, 1) })
+	th.Call()
+	th.Call()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+	assert.Equal(t, true, th.Flush())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+	assert.Equal(t, false, th.Flush())
+
+	th.Call()
+	assert.Equal(t, true, th.Stop())
+	th.Call()
+	assert.Equal(t, false, th.Flush())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+}
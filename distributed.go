@@ -0,0 +1,182 @@
+package sync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// distributedOncePoll is how often a DistributedOnce checks the backend while another owner holds the lease.
+const distributedOncePoll = 50 * time.Millisecond
+
+// OnceBackend is the shared state through which DistributedOnces in different processes or machines coordinate,
+// e.g. Redis or etcd. Leases must expire after their ttl, so that a crashed owner doesn't block the others forever.
+// MemoryBackend is an implementation for a single process, e.g. for tests.
+type OnceBackend interface {
+	// AcquireLease takes the lease on key for owner for ttl, and returns whether it did. It returns false if another
+	// owner holds an unexpired lease.
+	AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease drops the lease on key if owner holds it.
+	ReleaseLease(ctx context.Context, key, owner string) error
+	// IsDone returns whether key is marked as done.
+	IsDone(ctx context.Context, key string) (bool, error)
+	// MarkDone marks key as done.
+	MarkDone(ctx context.Context, key string) error
+}
+
+// DistributedOnce executes a function once across all the DistributedOnces using the same backend and key,
+// e.g. for a cluster wide one time job. The one which takes the lease on the key executes the function and, if it
+// returns true, marks the key as done in the backend. The others poll the backend till the key is done or the lease
+// is free again. The lease needs to outlast the execution of the function, else another owner may execute it too.
+// Clients should use NewDistributedOnce to create objects.
+type DistributedOnce struct {
+	backend OnceBackend
+	key     string
+	owner   string
+	lease   time.Duration
+	f       FuncType
+	mu      sync.Mutex // serializes the goroutines of this DistributedOnce
+}
+
+// NewDistributedOnce returns a DistributedOnce executing f once for key in backend, holding the lease for lease
+// while executing. An error is returned if backend or f is nil, key is empty or lease isn't positive.
+func NewDistributedOnce(backend OnceBackend, key string, lease time.Duration, f FuncType) (*DistributedOnce, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("backend can't be nil")
+	}
+	if key == "" {
+		return nil, fmt.Errorf("key can't be empty")
+	}
+	if lease <= 0 {
+		return nil, fmt.Errorf("lease needs to be positive, got %v", lease)
+	}
+	if f == nil {
+		return nil, fmt.Errorf("function can't be nil")
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	return &DistributedOnce{backend: backend, key: key, owner: hex.EncodeToString(id[:]), lease: lease, f: f}, nil
+}
+
+// Do executes the function if the key isn't done yet, blocking while another owner holds the lease. The key is
+// marked as done only if the function returns true, otherwise the next Do() executes it again. Do returns true for
+// the caller which executed the function, and the first error of the backend or ctx.Err() if ctx is done before.
+// If the function panics, the lease is released and the panic is raised in the caller.
+func (d *DistributedOnce) Do(ctx context.Context) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := time.NewTicker(distributedOncePoll)
+	defer t.Stop()
+	for {
+		if done, err := d.backend.IsDone(ctx, d.key); done || err != nil {
+			return false, err
+		}
+		acquired, err := d.backend.AcquireLease(ctx, d.key, d.owner, d.lease)
+		if err != nil {
+			return false, err
+		}
+		if acquired {
+			return d.execute(ctx)
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// Done returns whether the key is done. If block = true, it polls the backend till the key is done or ctx is done.
+// It returns the first error of the backend, or ctx.Err() if ctx is done first.
+func (d *DistributedOnce) Done(ctx context.Context, block bool) (bool, error) {
+	t := time.NewTicker(distributedOncePoll)
+	defer t.Stop()
+	for {
+		done, err := d.backend.IsDone(ctx, d.key)
+		if done || err != nil || !block {
+			return done, err
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// execute runs the function holding the lease.
+func (d *DistributedOnce) execute(ctx context.Context) (bool, error) {
+	// the lease is released even if ctx is done, so that the others don't wait for it to expire
+	defer d.backend.ReleaseLease(context.WithoutCancel(ctx), d.key, d.owner)
+
+	// the previous owner may have marked the key between IsDone and AcquireLease
+	if done, err := d.backend.IsDone(ctx, d.key); done || err != nil {
+		return false, err
+	}
+	if !d.f() {
+		return true, nil
+	}
+	return true, d.backend.MarkDone(ctx, d.key)
+}
+
+// MemoryBackend is a OnceBackend keeping its state in memory, so it only coordinates the DistributedOnces of a
+// single process. It's meant for tests and as a reference for other backends. The zero value is ready to use.
+// A MemoryBackend must not be copied after first use.
+type MemoryBackend struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease // guarded by mu
+	done   map[string]bool        // guarded by mu
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+// AcquireLease implements OnceBackend.
+func (b *MemoryBackend) AcquireLease(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if l, ok := b.leases[key]; ok && l.owner != owner && now.Before(l.expires) {
+		return false, nil
+	}
+	if b.leases == nil {
+		b.leases = make(map[string]memoryLease)
+	}
+	b.leases[key] = memoryLease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease implements OnceBackend.
+func (b *MemoryBackend) ReleaseLease(_ context.Context, key, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l, ok := b.leases[key]; ok && l.owner == owner {
+		delete(b.leases, key)
+	}
+	return nil
+}
+
+// IsDone implements OnceBackend.
+func (b *MemoryBackend) IsDone(_ context.Context, key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.done[key], nil
+}
+
+// MarkDone implements OnceBackend.
+func (b *MemoryBackend) MarkDone(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done == nil {
+		b.done = make(map[string]bool)
+	}
+	b.done[key] = true
+	return nil
+}
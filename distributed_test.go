@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDistributedOnce(t *testing.T) {
+	var b MemoryBackend
+	_, err := NewDistributedOnce(nil, "k", time.Second, returnTrue)
+	assert.NotEqual(t, err, nil)
+	_, err = NewDistributedOnce(&b, "", time.Second, returnTrue)
+	assert.NotEqual(t, err, nil)
+	_, err = NewDistributedOnce(&b, "k", 0, returnTrue)
+	assert.NotEqual(t, err, nil)
+	_, err = NewDistributedOnce(&b, "k", time.Second, nil)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestMemoryBackend(t *testing.T) {
+	var b MemoryBackend
+	ctx := context.Background()
+	ok, _ := b.AcquireLease(ctx, "k", "a", 10*time.Millisecond)
+	assert.Equal(t, true, ok)
+	ok, _ = b.AcquireLease(ctx, "k", "b", time.Second)
+	assert.Equal(t, false, ok)
+	// renewal by the owner
+	ok, _ = b.AcquireLease(ctx, "k", "a", 10*time.Millisecond)
+	assert.Equal(t, true, ok)
+
+	// expiry
+	time.Sleep(15 * time.Millisecond)
+	ok, _ = b.AcquireLease(ctx, "k", "b", time.Second)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, nil, b.ReleaseLease(ctx, "k", "a"))
+	ok, _ = b.AcquireLease(ctx, "k", "a", time.Second)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, nil, b.ReleaseLease(ctx, "k", "b"))
+	ok, _ = b.AcquireLease(ctx, "k", "a", time.Second)
+	assert.Equal(t, true, ok)
+
+	done, _ := b.IsDone(ctx, "k")
+	assert.Equal(t, false, done)
+	assert.Equal(t, nil, b.MarkDone(ctx, "k"))
+	done, _ = b.IsDone(ctx, "k")
+	assert.Equal(t, true, done)
+}
+
+func TestDistributedOnce(t *testing.T) {
+	var b MemoryBackend
+	var calls int32
+	f := func() bool {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return true
+	}
+
+	// separate DistributedOnces, as in separate processes, coordinate through the backend
+	var wg sync.WaitGroup
+	var executed int32
+	for i := 0; i < 5; i++ {
+		d, err := NewDistributedOnce(&b, "job", time.Second, f)
+		assert.Equal(t, err, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ran, err := d.Do(context.Background())
+			assert.Equal(t, nil, err)
+			if ran {
+				atomic.AddInt32(&executed, 1)
+			}
+			done, err := d.Done(context.Background(), false)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, done)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&executed))
+}
+
+func TestDistributedOnceFailureAndWait(t *testing.T) {
+	var b MemoryBackend
+	ok := false
+	d, _ := NewDistributedOnce(&b, "job", time.Second, func() bool { return ok })
+	ran, err := d.Do(context.Background())
+	assert.Equal(t, true, ran)
+	assert.Equal(t, nil, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done, err := d.Done(ctx, true)
+	assert.Equal(t, false, done)
+	assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+
+	// a lease held by another owner makes Do wait
+	b.AcquireLease(context.Background(), "job", "other", time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = d.Do(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	b.ReleaseLease(context.Background(), "job", "other")
+	ok = true
+	ran, err = d.Do(context.Background())
+	assert.Equal(t, true, ran)
+	assert.Equal(t, nil, err)
+	done, _ = d.Done(context.Background(), true)
+	assert.Equal(t, true, done)
+}
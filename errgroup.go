@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ErrGroup runs functions in their own goroutines and collects the first error they return, like the errgroup
+// package of golang.org/x/sync. Unlike it, a panic in a function doesn't crash the program: it's recovered and
+// reported by Wait() as a *PanicError, same as a suppressed panic of a Once.
+// Its zero value is ready to use, with no limit on the number of goroutines and no context.
+// An ErrGroup must not be copied after first use.
+type ErrGroup struct {
+	wg      sync.WaitGroup
+	sem     chan struct{} // a token per active goroutine when there is a limit
+	cancel  context.CancelCauseFunc
+	errOnce sync.Once
+	err     error
+}
+
+// NewErrGroup returns an ErrGroup and a context derived from ctx, which is cancelled when a function fails
+// or Wait() returns, whichever happens first.
+func NewErrGroup(ctx context.Context) (*ErrGroup, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &ErrGroup{cancel: cancel}, ctx
+}
+
+// Go calls f in a new goroutine. If a limit is set with SetLimit, Go blocks till the number of active goroutines
+// is below it. The first non-nil error returned by a function, or the first panic, cancels the context of
+// the group and is returned by Wait().
+func (g *ErrGroup) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(f)
+}
+
+// TryGo is same as Go() but only starts the goroutine if the number of active goroutines is below the limit.
+// It returns whether it did.
+func (g *ErrGroup) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(f)
+	return true
+}
+
+// SetLimit limits the number of active goroutines to n. A negative n means no limit.
+// The limit can't be changed while goroutines are active, SetLimit panics in that case.
+func (g *ErrGroup) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("sync: modify limit while %d goroutines in the group are still active", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Wait blocks till all the functions started with Go() and TryGo() have returned, then returns the first error
+// or panic of a function, if any.
+func (g *ErrGroup) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// start calls f in a new goroutine, recovering its panic. The caller has taken a token of sem already, if needed.
+func (g *ErrGroup) start(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		var err error
+		defer func() {
+			if p := recover(); p != nil {
+				err = newPanicError(p)
+			}
+			if err != nil {
+				g.setErr(err)
+			}
+		}()
+		err = f()
+	}()
+}
+
+// setErr records err if it's the first error of the group and cancels the context.
+func (g *ErrGroup) setErr(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		if g.cancel != nil {
+			g.cancel(err)
+		}
+	})
+}
+
+// done releases the token of a goroutine which has returned.
+func (g *ErrGroup) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrGroup(t *testing.T) {
+	var g ErrGroup
+	assert.Equal(t, nil, g.Wait())
+
+	var calls int32
+	for i := 0; i < 5; i++ {
+		g.Go(func() error { atomic.AddInt32(&calls, 1); return nil })
+	}
+	assert.Equal(t, nil, g.Wait())
+	assert.Equal(t, int32(5), calls)
+
+	errFirst := errors.New("first")
+	g.Go(func() error { return errFirst })
+	assert.Equal(t, errFirst, g.Wait())
+}
+
+func TestErrGroupContext(t *testing.T) {
+	errFail := errors.New("fail")
+	g, ctx := NewErrGroup(context.Background())
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.Go(func() error { return errFail })
+	assert.Equal(t, errFail, g.Wait())
+	assert.Equal(t, errFail, context.Cause(ctx))
+
+	// the context is cancelled by Wait even without errors
+	g, ctx = NewErrGroup(context.Background())
+	g.Go(func() error { return nil })
+	assert.Equal(t, nil, g.Wait())
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestErrGroupPanic(t *testing.T) {
+	var g ErrGroup
+	g.Go(func() error { panic("boom") })
+	err := g.Wait()
+	assert.True(t, errors.Is(err, ErrPanicked))
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, "boom", pe.Value)
+}
+
+func TestErrGroupLimit(t *testing.T) {
+	const n = 2
+	var g ErrGroup
+	g.SetLimit(n)
+
+	var running, max int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			r := atomic.AddInt32(&running, 1)
+			for m := atomic.LoadInt32(&max); r > m && !atomic.CompareAndSwapInt32(&max, m, r); m = atomic.LoadInt32(&max) {
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	assert.Equal(t, nil, g.Wait())
+	assert.True(t, atomic.LoadInt32(&max) <= n)
+
+	release := make(chan struct{})
+	g.SetLimit(1)
+	assert.Equal(t, true, g.TryGo(func() error { <-release; return nil }))
+	assert.Equal(t, false, g.TryGo(func() error { return nil }))
+	assert.Panics(t, func() { g.SetLimit(2) })
+	close(release)
+	assert.Equal(t, nil, g.Wait())
+}
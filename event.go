@@ -0,0 +1,61 @@
+package sync
+
+import "context"
+
+// Event is a manual reset event: goroutines wait for it to be Set(), and it stays set, releasing all waiters, till
+// Clear() is called, after which waiters block again till the next Set(). It's a Once whose Do() sets the event and
+// whose Reset() clears it, so waiting behaves same as Once.Done. Clients should use NewEvent to create objects.
+type Event struct {
+	o *Once
+}
+
+// NewEvent returns an Event which isn't set.
+func NewEvent() *Event {
+	o, _ := NewDefaultOnce(func() bool { return true })
+	return &Event{o: o}
+}
+
+// Set sets the event, releasing all waiters. It returns false if the event was set already or is closed.
+func (e *Event) Set() bool {
+	return e.o.Do()
+}
+
+// Clear clears the event, so that waiters block till the next Set(). It also undoes Close().
+// It returns whether the event was set.
+func (e *Event) Clear() bool {
+	return e.o.Reset()
+}
+
+// IsSet returns whether the event is set. It never blocks.
+func (e *Event) IsSet() bool {
+	return e.o.Done(false)
+}
+
+// Wait returns whether the event is set. If block = true, it blocks till the event is set or closed, same as
+// Once.Done.
+func (e *Event) Wait(block bool) bool {
+	return e.o.Done(block)
+}
+
+// WaitCtx blocks till the event is set and returns nil. It returns ErrClosed if the event is closed first,
+// and ctx.Err() if ctx is done first.
+func (e *Event) WaitCtx(ctx context.Context) error {
+	if e.o.DoneContext(ctx) {
+		return nil
+	}
+	if e.o.Closed() {
+		return ErrClosed
+	}
+	return ctx.Err()
+}
+
+// DoneChan returns a channel which is closed when the event is set or closed. After Clear(), DoneChan returns
+// a new channel for the next Set().
+func (e *Event) DoneChan() <-chan struct{} {
+	return e.o.DoneChan()
+}
+
+// Close releases all goroutines waiting for the event without setting it, and makes Set() a no-op till Clear().
+func (e *Event) Close() {
+	e.o.Close()
+}
@@ -0,0 +1,53 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent(t *testing.T) {
+	e := NewEvent()
+	assert.Equal(t, false, e.IsSet())
+	assert.Equal(t, false, e.Wait(false))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, true, e.Wait(true))
+		}()
+	}
+	time.Sleep(time.Millisecond * 2)
+	assert.Equal(t, true, e.Set())
+	assert.Equal(t, false, e.Set())
+	wg.Wait()
+	<-e.DoneChan()
+
+	// waiters block again after Clear, till the next Set
+	assert.Equal(t, true, e.Clear())
+	assert.Equal(t, false, e.IsSet())
+	ch := e.DoneChan()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, e.WaitCtx(ctx))
+
+	go func() { time.Sleep(time.Millisecond * 2); e.Set() }()
+	assert.Equal(t, nil, e.WaitCtx(context.Background()))
+	<-ch
+}
+
+func TestEventClose(t *testing.T) {
+	e := NewEvent()
+	go func() { time.Sleep(time.Millisecond * 2); e.Close() }()
+	assert.Equal(t, false, e.Wait(true))
+	assert.Equal(t, ErrClosed, e.WaitCtx(context.Background()))
+	assert.Equal(t, false, e.Set())
+
+	assert.Equal(t, false, e.Clear())
+	assert.Equal(t, true, e.Set())
+}
@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// FairMutex is a mutual exclusion lock acquired in request order: Unlock hands the lock over to the goroutine which
+// has been waiting the longest, so no goroutine starves under contention. The price is a lower throughput than
+// Mutex, since the lock can't be taken by a running goroutine while the next one in line is being woken up.
+// Its zero value is an unlocked mutex. A FairMutex must not be copied after first use.
+type FairMutex struct {
+	mu      sync.Mutex
+	locked  bool      // guarded by mu
+	waiters list.List // *fairWaiter in arrival order. guarded by mu
+}
+
+type fairWaiter struct {
+	ready chan struct{} // closed when the lock is handed over to the waiter
+}
+
+// Lock locks m, blocking till it's available and the goroutines which asked for it before got it.
+func (m *FairMutex) Lock() {
+	m.LockCtx(context.Background())
+}
+
+// TryLock locks m if it's available right away with no goroutine waiting for it, and returns whether it did.
+func (m *FairMutex) TryLock() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked {
+		return false
+	}
+	m.locked = true
+	return true
+}
+
+// LockCtx locks m same as Lock, unless ctx is done first. It returns nil if m was locked, else ctx.Err().
+func (m *FairMutex) LockCtx(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.locked {
+		m.locked = true
+		m.mu.Unlock()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	w := &fairWaiter{ready: make(chan struct{})}
+	elem := m.waiters.PushBack(w)
+	m.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		select {
+		case <-w.ready:
+			// handed over right when ctx got done, keep the lock rather than passing it on
+			return nil
+		default:
+		}
+		m.waiters.Remove(elem)
+		return ctx.Err()
+	}
+}
+
+// Unlock unlocks m, handing it over to the goroutine waiting the longest, if any. Same as with Mutex, m can be
+// unlocked by another goroutine than the one which locked it. Unlock panics if m isn't locked.
+func (m *FairMutex) Unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked {
+		panic("sync: unlock of unlocked fair mutex")
+	}
+	if front := m.waiters.Front(); front != nil {
+		// m stays locked, now by the waiter
+		m.waiters.Remove(front)
+		close(front.Value.(*fairWaiter).ready)
+		return
+	}
+	m.locked = false
+}
+
+// Waiting returns the number of goroutines waiting for m.
+func (m *FairMutex) Waiting() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.waiters.Len()
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairMutex(t *testing.T) {
+	var m FairMutex
+	assert.Panics(t, func() { m.Unlock() })
+
+	m.Lock()
+	assert.Equal(t, false, m.TryLock())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.LockCtx(ctx))
+	assert.Equal(t, 0, m.Waiting())
+
+	// a TryLock doesn't jump the queue, even while the lock is being handed over
+	go m.Lock()
+	for m.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	m.Unlock()
+	assert.Equal(t, false, m.TryLock())
+	m.Unlock()
+
+	assert.Equal(t, true, m.TryLock())
+	m.Unlock()
+}
+
+func TestFairMutexOrder(t *testing.T) {
+	var m FairMutex
+	m.Lock()
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Lock()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			m.Unlock()
+		}()
+		// queue the goroutines up one after the other
+		for m.Waiting() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	m.Unlock()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+}
+
+func TestFairMutexCanceledWaiter(t *testing.T) {
+	var m FairMutex
+	m.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- m.LockCtx(ctx) }()
+	for m.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	assert.Equal(t, 0, m.Waiting())
+
+	m.Unlock()
+	assert.Equal(t, true, m.TryLock())
+	m.Unlock()
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// fifoWaiter is a goroutine blocked in waitForFIFO. Waiters released together are linked through next
+// and wake up one after the other, each waking up the next one, so that they are released in arrival order.
+// The links are guarded by stateMu.
+type fifoWaiter struct {
+	ch   chan struct{} // closed to wake up the waiter
+	prev *fifoWaiter
+	next *fifoWaiter
+}
+
+// waitForFIFO is waitFor for a Once created with WithFIFOWaiters.
+func (d *Once) waitForFIFO(ctx context.Context, ready func() bool) bool {
+	waited := false
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	for ctx.Err() == nil {
+		if ready() {
+			if waited {
+				atomic.AddUint64(&d.released, 1)
+			}
+			return true
+		}
+		w := &fifoWaiter{ch: make(chan struct{}), prev: d.fifoTail}
+		if d.fifoTail == nil {
+			d.fifoHead = w
+		} else {
+			d.fifoTail.next = w
+		}
+		d.fifoTail = w
+
+		atomic.AddInt32(&d.waiters, 1)
+		d.stateMu.Unlock()
+		select {
+		case <-w.ch:
+		case <-ctx.Done():
+		}
+		d.stateMu.Lock()
+		atomic.AddInt32(&d.waiters, -1)
+		waited = true
+
+		select {
+		case <-w.ch:
+			// woken up, hand over to the next waiter. It can't return before this one releases the lock
+			wake(w.next)
+		default:
+			// left because of ctx before being woken up
+			d.unlinkFIFO(w)
+		}
+	}
+	return ready()
+}
+
+// releaseFIFO wakes up the waiters queued so far, in arrival order. Waiters queueing up later, e.g. because
+// they found the state unchanged after waking up, wait for the next release. Must be called holding stateMu.
+func (d *Once) releaseFIFO() {
+	head := d.fifoHead
+	d.fifoHead, d.fifoTail = nil, nil
+	wake(head)
+}
+
+// unlinkFIFO removes w, which hasn't been woken up, from the queue or from the waiters released together with it.
+// In the latter case w isn't the first of them, as that one is woken up by the release, so the waiter ahead of w
+// hands over to the one after it instead. Must be called holding stateMu.
+func (d *Once) unlinkFIFO(w *fifoWaiter) {
+	if w.prev != nil {
+		w.prev.next = w.next
+	} else if d.fifoHead == w {
+		d.fifoHead = w.next
+	}
+	if w.next != nil {
+		w.next.prev = w.prev
+	} else if d.fifoTail == w {
+		d.fifoTail = w.prev
+	}
+	w.prev, w.next = nil, nil
+}
+
+// wake wakes up w, if any.
+func wake(w *fifoWaiter) {
+	if w != nil {
+		close(w.ch)
+	}
+}
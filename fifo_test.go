@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startFIFOWaiters starts n goroutines blocking on Done(true), one after the other, and returns the channel
+// to which each sends its index after getting unblocked.
+func startFIFOWaiters(t *testing.T, o *Once, n int) <-chan int {
+	order := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			o.Done(true)
+			order <- i
+		}(i)
+		waitForWaiters(t, o, i+1)
+	}
+	return order
+}
+
+func TestFIFOWaiters(t *testing.T) {
+	const n = 8
+	o, err := NewOnceWithOptions([]FuncType{returnTrue}, WithLazyDone(true), WithFIFOWaiters())
+	assert.Equal(t, err, nil)
+
+	order := startFIFOWaiters(t, o, n)
+	assert.Equal(t, true, o.Do())
+	for i := 0; i < n; i++ {
+		assert.Equal(t, i, <-order)
+	}
+	assert.Equal(t, 0, o.Waiters())
+}
+
+func TestFIFOWaitersClose(t *testing.T) {
+	const n = 8
+	o, err := NewOnceWithOptions([]FuncType{returnTrue}, WithFIFOWaiters())
+	assert.Equal(t, err, nil)
+
+	order := startFIFOWaiters(t, o, n)
+	o.Close()
+	for i := 0; i < n; i++ {
+		assert.Equal(t, i, <-order)
+	}
+}
+
+func TestFIFOWaitersContextDone(t *testing.T) {
+	o, err := NewOnceWithOptions([]FuncType{returnTrue}, WithFIFOWaiters())
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, false, o.Done(true))
+	}()
+	waitForWaiters(t, o, 1)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, context.Canceled, WaitAllContext(ctx, o))
+	}()
+	waitForWaiters(t, o, 2)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, false, o.Done(true))
+	}()
+	waitForWaiters(t, o, 3)
+
+	// the waiter which left must not hold up the ones queued behind it
+	cancel()
+	waitForWaiters(t, o, 2)
+	o.Close()
+	wg.Wait()
+}
+
+func TestFIFOWaitersContextDoneUnlinked(t *testing.T) {
+	o, err := NewOnceWithOptions([]FuncType{returnTrue}, WithFIFOWaiters())
+	assert.Equal(t, err, nil)
+
+	// waiters which leave because of their context don't stay queued on a Once which never completes
+	for i := 0; i < 200; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond*50)
+		assert.Equal(t, false, o.DoneContext(ctx))
+		cancel()
+	}
+	o.stateMu.Lock()
+	assert.Nil(t, o.fifoHead)
+	assert.Nil(t, o.fifoTail)
+	o.stateMu.Unlock()
+
+	order := startFIFOWaiters(t, o, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	go o.DoneContext(ctx)
+	waitForWaiters(t, o, 4)
+	cancel()
+	waitForWaiters(t, o, 3)
+	assert.Equal(t, true, o.Do())
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, i, <-order)
+	}
+}
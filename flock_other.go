@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package sync
+
+import "errors"
+
+// lockFile isn't supported on this platform.
+func lockFile(path string) (func(), error) {
+	return nil, errors.New("file locks are not supported on this platform")
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"context"
+	"errors"
+)
+
+// Future is a result of type T which is produced by one goroutine and consumed by others: the producer calls
+// Complete() once the result is known, and consumers wait for it with Get(). Complete is enforced to take effect
+// exactly once by a Once, so racing producers are safe: only the first one sets the result.
+// Clients should use NewFuture to create objects. A Future must not be copied after first use.
+type Future[T any] struct {
+	noCopy noCopy
+	o      *Once
+	value  T
+	err    error
+}
+
+// NewFuture returns a Future which isn't complete.
+func NewFuture[T any]() *Future[T] {
+	// lazyDone = true, so the Future becomes DONE only after the result is stored
+	o, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	return &Future[T]{o: o}
+}
+
+// Complete sets the result of the Future and releases the consumers. It returns true for the call which set
+// the result, and false if the Future was completed already, in which case value and err are ignored.
+func (f *Future[T]) Complete(value T, err error) bool {
+	return f.o.DoAlso(func() bool {
+		f.value, f.err = value, err
+		return true
+	})
+}
+
+// Get blocks till the Future is complete and returns its result. If ctx is done first, it returns the zero value
+// and ctx.Err().
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	if !f.o.DoneContext(ctx) {
+		var zero T
+		return zero, ctx.Err()
+	}
+	return f.value, f.err
+}
+
+// TryGet returns the result of the Future and true if it's complete, without blocking. Otherwise it returns
+// the zero value, a nil error and false.
+func (f *Future[T]) TryGet() (T, error, bool) {
+	if !f.o.Done(false) {
+		var zero T
+		return zero, nil, false
+	}
+	return f.value, f.err, true
+}
+
+// Done returns whether the Future is complete. If block = true, it blocks till it is.
+func (f *Future[T]) Done(block bool) bool {
+	return f.o.Done(block)
+}
+
+// Wait blocks till the Future is complete, same as Done(true).
+func (f *Future[T]) Wait() {
+	f.o.Done(true)
+}
+
+// DoneChan returns a channel which is closed when the Future is complete, to select on it.
+func (f *Future[T]) DoneChan() <-chan struct{} {
+	return f.o.DoneChan()
+}
+
+// WhenAll returns a Future which completes once all of fs are complete. Its value has the values of fs in the same
+// order, and its error combines the errors of fs with errors.Join, so it's nil only if none of fs failed.
+// If no Future is given, the returned one is complete right away.
+func WhenAll[T any](fs ...*Future[T]) *Future[[]T] {
+	all := NewFuture[[]T]()
+	if len(fs) == 0 {
+		all.Complete([]T{}, nil)
+		return all
+	}
+	go func() {
+		values := make([]T, len(fs))
+		errs := make([]error, len(fs))
+		for i, f := range fs {
+			<-f.DoneChan()
+			values[i], errs[i], _ = f.TryGet()
+		}
+		all.Complete(values, errors.Join(errs...))
+	}()
+	return all
+}
+
+// WhenAny returns a Future which completes with the result of the first of fs to complete, be it a success or
+// a failure. If no Future is given, the returned one completes right away with an error.
+func WhenAny[T any](fs ...*Future[T]) *Future[T] {
+	first := NewFuture[T]()
+	if len(fs) == 0 {
+		var zero T
+		first.Complete(zero, errors.New("no futures given"))
+		return first
+	}
+	for _, f := range fs {
+		go func(f *Future[T]) {
+			// stop waiting for f once another one won, so incomplete futures don't hold goroutines
+			select {
+			case <-f.DoneChan():
+				v, err, _ := f.TryGet()
+				first.Complete(v, err)
+			case <-first.DoneChan():
+			}
+		}(f)
+	}
+	return first
+}
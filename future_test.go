@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuture(t *testing.T) {
+	f := NewFuture[int]()
+	v, err, ok := f.TryGet()
+	assert.Equal(t, 0, v)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, false, f.Done(false))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			v, err := f.Get(context.Background())
+			assert.Equal(t, 42, v)
+			assert.Equal(t, nil, err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 2)
+	assert.Equal(t, true, f.Complete(42, nil))
+	assert.Equal(t, false, f.Complete(7, errors.New("late")))
+	wg.Wait()
+	<-f.DoneChan()
+
+	v, err, ok = f.TryGet()
+	assert.Equal(t, 42, v)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+}
+
+func TestFutureRacingProducers(t *testing.T) {
+	f := NewFuture[int]()
+	var wg sync.WaitGroup
+	winners := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if f.Complete(i, nil) {
+				winners <- i
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(winners)
+	assert.Equal(t, 1, len(winners))
+	v, _ := f.Get(context.Background())
+	assert.Equal(t, <-winners, v)
+}
+
+func TestFutureGetCtx(t *testing.T) {
+	f := NewFuture[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	v, err := f.Get(ctx)
+	assert.Equal(t, "", v)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	errFail := errors.New("fail")
+	f.Complete("partial", errFail)
+	v, err = f.Get(ctx)
+	assert.Equal(t, "partial", v)
+	assert.Equal(t, errFail, err)
+}
+
+func TestWhenAll(t *testing.T) {
+	empty := WhenAll[int]()
+	assert.Equal(t, true, empty.Done(false))
+	v, err := empty.Get(context.Background())
+	assert.Equal(t, []int{}, v)
+	assert.Equal(t, nil, err)
+
+	fs := []*Future[int]{NewFuture[int](), NewFuture[int](), NewFuture[int]()}
+	all := WhenAll(fs...)
+	fs[2].Complete(3, nil)
+	fs[0].Complete(1, nil)
+	time.Sleep(time.Millisecond * 2)
+	assert.Equal(t, false, all.Done(false))
+	fs[1].Complete(2, nil)
+	v, err = all.Get(context.Background())
+	assert.Equal(t, []int{1, 2, 3}, v)
+	assert.Equal(t, nil, err)
+
+	// the errors of all the futures are reported
+	err1, err2 := errors.New("first"), errors.New("second")
+	fs = []*Future[int]{NewFuture[int](), NewFuture[int](), NewFuture[int]()}
+	fs[0].Complete(0, err1)
+	fs[1].Complete(5, nil)
+	fs[2].Complete(0, err2)
+	v, err = WhenAll(fs...).Get(context.Background())
+	assert.Equal(t, []int{0, 5, 0}, v)
+	assert.True(t, errors.Is(err, err1))
+	assert.True(t, errors.Is(err, err2))
+}
+
+func TestWhenAny(t *testing.T) {
+	_, err := WhenAny[int]().Get(context.Background())
+	assert.NotEqual(t, nil, err)
+
+	fs := []*Future[string]{NewFuture[string](), NewFuture[string](), NewFuture[string]()}
+	first := WhenAny(fs...)
+	assert.Equal(t, false, first.Done(false))
+	fs[1].Complete("second", nil)
+	v, err := first.Get(context.Background())
+	assert.Equal(t, "second", v)
+	assert.Equal(t, nil, err)
+
+	// later completions don't change the result
+	fs[0].Complete("first", nil)
+	time.Sleep(time.Millisecond)
+	v, _ = first.Get(context.Background())
+	assert.Equal(t, "second", v)
+
+	// a failure is a result too
+	errFail := errors.New("fail")
+	fs = []*Future[string]{NewFuture[string](), NewFuture[string]()}
+	fs[0].Complete("", errFail)
+	_, err = WhenAny(fs...).Get(context.Background())
+	assert.Equal(t, errFail, err)
+}
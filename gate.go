@@ -0,0 +1,57 @@
+package sync
+
+import "context"
+
+// Gate holds goroutines till it's opened, e.g. to hold workers till the config is loaded. Open() releases all the
+// waiters, and the ones coming later pass through. It's a Once whose Do() opens the gate, so waiting behaves same
+// as Once.Done. A resettable Gate can be closed again to hold the next waiters. Clients should use NewGate to create
+// objects.
+type Gate struct {
+	o          *Once
+	resettable bool
+}
+
+// NewGate returns a closed Gate. If resettable = true, it can be closed again after being opened.
+func NewGate(resettable bool) *Gate {
+	o, _ := NewDefaultOnce(func() bool { return true })
+	return &Gate{o: o, resettable: resettable}
+}
+
+// Open opens the gate, releasing all the waiters. It returns false if the gate was open already.
+func (g *Gate) Open() bool {
+	return g.o.Do()
+}
+
+// Close closes an open resettable gate, so that the next waiters block till it's opened again. It returns whether
+// the gate was closed by this call: it's false if the gate was closed already, and always for a Gate which
+// isn't resettable, since such a gate stays open once opened.
+func (g *Gate) Close() bool {
+	if !g.resettable {
+		return false
+	}
+	return g.o.Reset()
+}
+
+// IsOpen returns whether the gate is open. It never blocks.
+func (g *Gate) IsOpen() bool {
+	return g.o.Done(false)
+}
+
+// Wait blocks till the gate is open.
+func (g *Gate) Wait() {
+	g.o.Done(true)
+}
+
+// WaitCtx blocks till the gate is open and returns nil, or returns ctx.Err() if ctx is done first.
+func (g *Gate) WaitCtx(ctx context.Context) error {
+	if g.o.DoneContext(ctx) {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// DoneChan returns a channel which is closed when the gate is open, to select on it. After the gate is closed
+// again, DoneChan returns a new channel.
+func (g *Gate) DoneChan() <-chan struct{} {
+	return g.o.DoneChan()
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	g := NewGate(false)
+	assert.Equal(t, false, g.IsOpen())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Wait()
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, g.WaitCtx(ctx))
+
+	assert.Equal(t, true, g.Open())
+	assert.Equal(t, false, g.Open())
+	wg.Wait()
+	assert.Equal(t, true, g.IsOpen())
+	assert.Equal(t, nil, g.WaitCtx(context.Background()))
+
+	// not resettable
+	assert.Equal(t, false, g.Close())
+	assert.Equal(t, true, g.IsOpen())
+}
+
+func TestGateResettable(t *testing.T) {
+	g := NewGate(true)
+	assert.Equal(t, false, g.Close())
+	g.Open()
+	<-g.DoneChan()
+
+	assert.Equal(t, true, g.Close())
+	assert.Equal(t, false, g.IsOpen())
+	ch := g.DoneChan()
+	select {
+	case <-ch:
+		t.Fatal("gate is closed")
+	default:
+	}
+	go g.Open()
+	<-ch
+	assert.Equal(t, true, g.IsOpen())
+}
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"sort"
+	"sync"
+)
+
+// globals is the package level registry of named Onces used by Global.
+var globals struct {
+	mu sync.Mutex
+	m  map[string]*Once
+}
+
+// Global returns the Once registered under name, so that different packages can coordinate on it without passing it around.
+// The first call for a name creates the Once same as NewOnce(false, suppressPanic, VerifyNone, f, fs...) and registers it.
+// Later calls return the same Once and ignore all other arguments.
+//
+// Beware that the functions of the first caller win: a package calling Global with its own functions gets a Once
+// which may execute functions of another package. Callers sharing a name should agree on what it executes.
+// An error is returned, and nothing is registered, if the Once can't be created, e.g. if any of the functions is nil.
+func Global(name string, suppressPanic bool, f FuncType, fs ...FuncType) (*Once, error) {
+	globals.mu.Lock()
+	defer globals.mu.Unlock()
+	if o, ok := globals.m[name]; ok {
+		return o, nil
+	}
+
+	o, err := NewOnce(false, suppressPanic, VerifyNone, f, fs...)
+	if err != nil {
+		return nil, err
+	}
+	if globals.m == nil {
+		globals.m = make(map[string]*Once)
+	}
+	globals.m[name] = o
+	return o, nil
+}
+
+// GlobalDone returns Done(false) of the Once registered under name, or false if there is none.
+func GlobalDone(name string) bool {
+	globals.mu.Lock()
+	o, ok := globals.m[name]
+	globals.mu.Unlock()
+	return ok && o.Done(false)
+}
+
+// GlobalNames returns the names registered with Global, in sorted order.
+func GlobalNames() []string {
+	globals.mu.Lock()
+	defer globals.mu.Unlock()
+	names := make([]string, 0, len(globals.m))
+	for name := range globals.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ClearGlobals empties the registry, so that the next Global call for any name creates a new Once.
+// Onces already returned by Global are not affected. It's meant for tests.
+func ClearGlobals() {
+	globals.mu.Lock()
+	globals.m = nil
+	globals.mu.Unlock()
+}
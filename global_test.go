@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobal(t *testing.T) {
+	ClearGlobals()
+	defer ClearGlobals()
+
+	a, err := Global("a", false, returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, GlobalDone("a"))
+	assert.Equal(t, false, GlobalDone("b"))
+
+	// later functions are ignored
+	same, err := Global("a", true, returnFalse)
+	assert.Equal(t, err, nil)
+	assert.True(t, a == same)
+	assert.Equal(t, true, a.Do())
+	assert.Equal(t, true, GlobalDone("a"))
+
+	_, err = Global("b", false, nil)
+	assert.NotEqual(t, err, nil)
+	_, err = Global("b", false, returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []string{"a", "b"}, GlobalNames())
+
+	ClearGlobals()
+	assert.Equal(t, []string{}, GlobalNames())
+	fresh, err := Global("a", false, returnTrue)
+	assert.Equal(t, err, nil)
+	assert.True(t, a != fresh)
+	assert.Equal(t, false, GlobalDone("a"))
+}
+
+func TestGlobalConcurrent(t *testing.T) {
+	ClearGlobals()
+	defer ClearGlobals()
+
+	const n = 16
+	var wg sync.WaitGroup
+	onces := make([]*Once, n)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			o, err := Global("shared", false, returnTrue)
+			assert.Equal(t, err, nil)
+			onces[i] = o
+		}(i)
+	}
+	wg.Wait()
+	for _, o := range onces {
+		assert.True(t, o == onces[0])
+	}
+}
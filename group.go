@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedOnce is a collection of Onces identified by a key of type K, one Once per key, e.g. to initialize
+// one resource per tenant or connection. The Once for a key is created on first use of the key with lazyDone = true,
+// so callers of Do() for a key return only after the function of that key has finished. The zero value is ready to use.
+type KeyedOnce[K comparable] struct {
+	mu sync.Mutex
+	m  map[K]*Once
+}
+
+// Group is a KeyedOnce accepting keys of any type. The dynamic type of the keys must be comparable.
+type Group = KeyedOnce[any]
+
+// once returns the Once for key, creating it with f if it doesn't exist.
+func (g *KeyedOnce[K]) once(key K, f FuncType) (*Once, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if o, ok := g.m[key]; ok {
+		return o, nil
+	}
+
+	o, err := NewOnce(true, false, VerifyNone, f)
+	if err != nil {
+		return nil, err
+	}
+	if g.m == nil {
+		g.m = make(map[K]*Once)
+	}
+	g.m[key] = o
+	return o, nil
+}
+
+// Do executes f once for key. It behaves same as Once.Do for the Once of key.
+// Only the f given by the first caller for a key is used, later callers' f is ignored.
+func (g *KeyedOnce[K]) Do(key K, f FuncType) (bool, error) {
+	o, err := g.once(key, f)
+	if err != nil {
+		return false, err
+	}
+	return o.Do(), nil
+}
+
+// DoContext is same as Do but a caller blocked on an in-flight execution for key gives up when ctx is done.
+// See Once.DoContext.
+func (g *KeyedOnce[K]) DoContext(ctx context.Context, key K, f FuncType) (bool, error) {
+	o, err := g.once(key, f)
+	if err != nil {
+		return false, err
+	}
+	return o.DoContext(ctx)
+}
+
+// Forget removes the Once of key from the group. The next Do() for key creates a new Once and executes again.
+// Callers already holding the old Once are not affected.
+func (g *KeyedOnce[K]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.m, key)
+}
+
+// get returns the Once of key, or nil if there is none.
+func (g *KeyedOnce[K]) get(key K) *Once {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.m[key]
+}
+
+// Done returns if the Once of key is DONE. It returns false for a key which hasn't been used. Done never blocks.
+func (g *KeyedOnce[K]) Done(key K) bool {
+	o := g.get(key)
+	return o != nil && o.Done(false)
+}
+
+// Reset resets the Once of key, so that the next Do() for key executes the function given by the first caller again.
+// It returns what Once.Reset returned, or false for a key which hasn't been used. Use Forget to also drop the function.
+func (g *KeyedOnce[K]) Reset(key K) bool {
+	o := g.get(key)
+	return o != nil && o.Reset()
+}
+
+// CompletedKeys returns the keys whose Once is DONE, in no particular order.
+func (g *KeyedOnce[K]) CompletedKeys() []K {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keys := make([]K, 0, len(g.m))
+	for key, o := range g.m {
+		if o.Done(false) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupDo(t *testing.T) {
+	var g Group
+	var a, b int32
+	fa := func() bool { atomic.AddInt32(&a, 1); return true }
+	fb := func() bool { atomic.AddInt32(&b, 1); return true }
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for i := 0; i < 5; i++ {
+		go func() { g.Do("a", fa); wg.Done() }()
+		go func() { g.Do("b", fb); wg.Done() }()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), a)
+	assert.Equal(t, int32(1), b)
+
+	res, err := g.Do("a", fa)
+	assert.Equal(t, false, res)
+	assert.Equal(t, err, nil)
+}
+
+func TestGroupDoContext(t *testing.T) {
+	var g Group
+	go func() { g.Do("slow", returnTrueWithDelay(time.Millisecond*10)) }()
+	time.Sleep(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	res, err := g.DoContext(ctx, "slow", returnTrue)
+	assert.Equal(t, false, res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// other keys are not affected
+	res, err = g.DoContext(context.Background(), "fast", returnTrue)
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+}
+
+func TestGroupForget(t *testing.T) {
+	var g Group
+	executed := 0
+	f := func() bool { executed++; return true }
+
+	g.Do(1, f)
+	g.Do(1, f)
+	assert.Equal(t, 1, executed)
+	g.Forget(1)
+	g.Do(1, f)
+	assert.Equal(t, 2, executed)
+}
+
+func TestKeyedOnce(t *testing.T) {
+	var k KeyedOnce[string]
+	calls := map[string]int{}
+	f := func(key string) FuncType {
+		return func() bool { calls[key]++; return true }
+	}
+
+	assert.Equal(t, false, k.Done("a"))
+	assert.Equal(t, false, k.Reset("a"))
+	assert.Equal(t, []string{}, k.CompletedKeys())
+
+	res, err := k.Do("a", f("a"))
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+	res, err = k.Do("a", f("other"))
+	assert.Equal(t, false, res)
+	assert.Equal(t, err, nil)
+	k.Do("b", f("b"))
+	_, err = k.Do("c", nil)
+	assert.NotEqual(t, err, nil)
+
+	assert.Equal(t, true, k.Done("a"))
+	assert.Equal(t, false, k.Done("c"))
+	assert.ElementsMatch(t, []string{"a", "b"}, k.CompletedKeys())
+
+	// Reset keeps the function of the first caller
+	assert.Equal(t, true, k.Reset("a"))
+	assert.Equal(t, false, k.Done("a"))
+	assert.ElementsMatch(t, []string{"b"}, k.CompletedKeys())
+	res, _ = k.Do("a", f("other"))
+	assert.Equal(t, true, res)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, calls)
+}
@@ -0,0 +1,136 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// LockEvent describes one acquisition of an instrumented lock. It's reported to the LockObserver once the lock
+// is released, so it has both durations.
+type LockEvent struct {
+	Name      string        // name given to the lock
+	Wait      time.Duration // time spent blocked acquiring the lock
+	Hold      time.Duration // time the lock was held
+	Contended bool          // whether the lock was held by another goroutine when requested
+}
+
+// LockObserver is called with the LockEvent of every release of an instrumented lock, e.g. to export the metrics
+// to Prometheus or expvar. It's called by the goroutine releasing the lock, after releasing it, so it may lock it
+// again, but it should be fast as it delays that goroutine.
+type LockObserver func(e LockEvent)
+
+// LockStats is a snapshot of the metrics of an InstrumentedMutex. See InstrumentedMutex.Stats.
+type LockStats struct {
+	Acquisitions uint64        // completed Lock(), successful TryLock() and LockCtx() calls
+	Contentions  uint64        // acquisitions which had to wait for another goroutine to release the mutex
+	Timeouts     uint64        // LockCtx() calls which gave up as their context was done
+	WaitTime     time.Duration // total time spent waiting for the mutex
+	HoldTime     time.Duration // total time the mutex was held, updated on every Unlock()
+	MaxWait      time.Duration // longest wait for the mutex
+	MaxHold      time.Duration // longest hold of the mutex
+}
+
+// InstrumentedMutex is a Mutex which records how long goroutines wait for it and hold it and how often they find
+// it locked, to diagnose lock contention without the execution tracer. The metrics are available from Stats(),
+// and each acquisition is reported to the LockObserver, if any, once released.
+// Create it with NewInstrumentedMutex. An InstrumentedMutex must not be copied after first use.
+type InstrumentedMutex struct {
+	mu        Mutex
+	name      string
+	observer  LockObserver
+	acquired  time.Time     // when the current holder acquired mu. guarded by mu
+	wait      time.Duration // wait of the current holder. guarded by mu
+	contended bool          // guarded by mu
+
+	acquisitions uint64
+	contentions  uint64
+	timeouts     uint64
+	waitTime     int64 // time.Duration
+	holdTime     int64 // time.Duration
+	maxWait      int64 // time.Duration
+	maxHold      int64 // time.Duration
+}
+
+// NewInstrumentedMutex returns an unlocked InstrumentedMutex. name identifies it in the LockEvents given to
+// observer, which may be nil to only collect Stats().
+func NewInstrumentedMutex(name string, observer LockObserver) *InstrumentedMutex {
+	return &InstrumentedMutex{name: name, observer: observer}
+}
+
+// Lock locks m, blocking till it's available.
+func (m *InstrumentedMutex) Lock() {
+	m.LockCtx(context.Background())
+}
+
+// TryLock locks m if it's available right away and returns whether it did. A failed TryLock isn't counted.
+func (m *InstrumentedMutex) TryLock() bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	m.locked(0, false)
+	return true
+}
+
+// LockCtx locks m, blocking till it's available or ctx is done. It returns nil if m was locked, else ctx.Err().
+func (m *InstrumentedMutex) LockCtx(ctx context.Context) error {
+	if m.TryLock() {
+		return nil
+	}
+	start := time.Now()
+	if err := m.mu.LockCtx(ctx); err != nil {
+		atomic.AddUint64(&m.timeouts, 1)
+		atomic.AddInt64(&m.waitTime, int64(time.Since(start)))
+		return err
+	}
+	m.locked(time.Since(start), true)
+	return nil
+}
+
+// Unlock unlocks m and reports the acquisition to the LockObserver. It panics if m isn't locked.
+func (m *InstrumentedMutex) Unlock() {
+	e := LockEvent{Name: m.name, Wait: m.wait, Hold: time.Since(m.acquired), Contended: m.contended}
+	m.mu.Unlock()
+	atomic.AddInt64(&m.holdTime, int64(e.Hold))
+	storeMax(&m.maxHold, int64(e.Hold))
+	if m.observer != nil {
+		m.observer(e)
+	}
+}
+
+// Stats returns the metrics of m. They are read one at a time, so while m is in use they may not be consistent
+// with each other.
+func (m *InstrumentedMutex) Stats() LockStats {
+	return LockStats{
+		Acquisitions: atomic.LoadUint64(&m.acquisitions),
+		Contentions:  atomic.LoadUint64(&m.contentions),
+		Timeouts:     atomic.LoadUint64(&m.timeouts),
+		WaitTime:     time.Duration(atomic.LoadInt64(&m.waitTime)),
+		HoldTime:     time.Duration(atomic.LoadInt64(&m.holdTime)),
+		MaxWait:      time.Duration(atomic.LoadInt64(&m.maxWait)),
+		MaxHold:      time.Duration(atomic.LoadInt64(&m.maxHold)),
+	}
+}
+
+// locked records an acquisition of mu, which must be held by the caller.
+func (m *InstrumentedMutex) locked(wait time.Duration, contended bool) {
+	m.acquired = time.Now()
+	m.wait = wait
+	m.contended = contended
+	atomic.AddUint64(&m.acquisitions, 1)
+	if contended {
+		atomic.AddUint64(&m.contentions, 1)
+		atomic.AddInt64(&m.waitTime, int64(wait))
+		storeMax(&m.maxWait, int64(wait))
+	}
+}
+
+// storeMax sets *addr to v if v is larger.
+func storeMax(addr *int64, v int64) {
+	for {
+		cur := atomic.LoadInt64(addr)
+		if v <= cur || atomic.CompareAndSwapInt64(addr, cur, v) {
+			return
+		}
+	}
+}
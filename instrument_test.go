@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentedMutex(t *testing.T) {
+	var mu sync.Mutex
+	var events []LockEvent
+	m := NewInstrumentedMutex("cache", func(e LockEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+
+	m.Lock()
+	time.Sleep(5 * time.Millisecond)
+	m.Unlock()
+
+	assert.Equal(t, true, m.TryLock())
+	assert.Equal(t, false, m.TryLock())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.LockCtx(ctx))
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(locked)
+		m.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	m.Unlock()
+	<-locked
+
+	s := m.Stats()
+	assert.Equal(t, uint64(3), s.Acquisitions)
+	assert.Equal(t, uint64(1), s.Contentions)
+	assert.Equal(t, uint64(1), s.Timeouts)
+	assert.GreaterOrEqual(t, s.MaxHold, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, s.MaxWait, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, s.WaitTime, s.MaxWait+5*time.Millisecond)
+
+	// the last event is reported after Unlock() returns in the other goroutine
+	for {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+		if n == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, "cache", events[0].Name)
+	assert.Equal(t, false, events[0].Contended)
+	assert.GreaterOrEqual(t, events[0].Hold, 5*time.Millisecond)
+	assert.Equal(t, time.Duration(0), events[1].Wait)
+	assert.Equal(t, true, events[2].Contended)
+	assert.Equal(t, s.MaxWait, events[2].Wait)
+	assert.GreaterOrEqual(t, s.HoldTime, events[0].Hold+events[1].Hold+events[2].Hold)
+}
+
+func TestOnceLockObserver(t *testing.T) {
+	events := make(chan LockEvent, 10)
+	release := make(chan struct{})
+	o, err := NewOnceWithOptions([]FuncType{func() bool {
+		<-release
+		return true
+	}}, WithLazyDone(true), WithLockObserver("config", func(e LockEvent) { events <- e }))
+	assert.Equal(t, err, nil)
+
+	go o.Do()
+	for o.State() != StateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan bool)
+	go func() { done <- o.Do() }()
+	for o.Stats().Blocked != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	assert.Equal(t, false, <-done)
+
+	// the executing goroutine reports after releasing the lock, so the blocked one may report first
+	var executed, blocked LockEvent
+	for i := 0; i < 2; i++ {
+		if e := <-events; e.Contended {
+			blocked = e
+		} else {
+			executed = e
+		}
+	}
+	assert.Equal(t, "config", executed.Name)
+	assert.GreaterOrEqual(t, executed.Hold, 5*time.Millisecond)
+	assert.Equal(t, true, blocked.Contended)
+	assert.GreaterOrEqual(t, blocked.Wait, 5*time.Millisecond)
+
+	// the fast path doesn't take the lock
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.TryDo())
+	assert.Equal(t, 0, len(events))
+}
@@ -0,0 +1,86 @@
+package sync
+
+import "sync"
+
+// Doer is implemented by types which execute function/s once. Do() blocks while another goroutine is executing
+// the function/s, TryDo() doesn't. Both return true only for the caller which executed the function/s.
+// Use it where code only needs to trigger the execution.
+type Doer interface {
+	Do() bool
+	TryDo() bool
+}
+
+// ErrDoer is implemented by types which execute function/s once and report the error of the execution.
+type ErrDoer interface {
+	DoE() (bool, error)
+}
+
+// AsErrDoer adapts a Doer which doesn't report errors to an ErrDoer whose DoE() always returns a nil error.
+func AsErrDoer(d Doer) ErrDoer {
+	return errDoer{d}
+}
+
+type errDoer struct {
+	Doer
+}
+
+func (d errDoer) DoE() (bool, error) {
+	return d.Do(), nil
+}
+
+// Waiter is implemented by types which can be observed for reaching the DONE state.
+// Done(true) and Wait() block till the DONE state or closure, when the channel returned by DoneChan() is closed.
+// Use it where code only needs to check or wait for readiness.
+type Waiter interface {
+	Done(block bool) bool
+	Wait()
+	DoneChan() <-chan struct{}
+}
+
+// DoneChanner is implemented by types exposing a channel which is closed when they complete, e.g. Once, Latch,
+// Event, Gate and Future.
+type DoneChanner interface {
+	DoneChan() <-chan struct{}
+}
+
+// Closer is implemented by types which can be closed to unblock all their waiters.
+type Closer interface {
+	Close()
+}
+
+var (
+	_ Doer    = (*Once)(nil)
+	_ ErrDoer = (*Once)(nil)
+	_ Waiter  = (*Once)(nil)
+	_ Closer  = (*Once)(nil)
+	_ Doer    = (*CompactOnce)(nil)
+	_ Waiter  = (*CompactOnce)(nil)
+	_ Closer  = (*CompactOnce)(nil)
+	_ Waiter  = (*OnceZ)(nil)
+	_ Closer  = (*OnceZ)(nil)
+	_ StdDoer = (*sync.Once)(nil)
+	_ StdDoer = (*StdOnce)(nil)
+	_ Closer  = (*Semaphore)(nil)
+	_ Closer  = (*Latch)(nil)
+	_ Closer  = (*Barrier)(nil)
+	_ Closer  = (*Event)(nil)
+	_ Waiter  = (*Future[any])(nil)
+	_ Waiter  = (*Shutdown)(nil)
+	_ Waiter  = (*OnceCloser)(nil)
+	_ Waiter  = (*RetryOnce)(nil)
+
+	_ DoneChanner = (*Once)(nil)
+	_ DoneChanner = (*Latch)(nil)
+	_ DoneChanner = (*Event)(nil)
+	_ DoneChanner = (*Gate)(nil)
+	_ DoneChanner = (*Future[any])(nil)
+	_ DoneChanner = (*Shutdown)(nil)
+	_ OnceBackend = (*MemoryBackend)(nil)
+
+	_ sync.Locker = (*Mutex)(nil)
+	_ sync.Locker = (*RWMutex)(nil)
+	_ sync.Locker = (*ReentrantMutex)(nil)
+	_ sync.Locker = (*FairMutex)(nil)
+	_ sync.Locker = (*UpgradableRWMutex)(nil)
+	_ sync.Locker = (*InstrumentedMutex)(nil)
+)
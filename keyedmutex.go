@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// KeyedMutex is a set of RWMutexes identified by a key, to serialize the work on each resource e.g. per ID without
+// one global lock. The mutex of a key is created on demand and freed once no goroutine holds it or waits for it,
+// so the memory used is bounded by the number of keys in use, not by the number of keys ever used.
+// The zero value is ready to use. A KeyedMutex must not be copied after first use.
+type KeyedMutex[K comparable] struct {
+	mu sync.Mutex
+	m  map[K]*keyedMutexEntry // guarded by mu
+}
+
+type keyedMutexEntry struct {
+	rw   RWMutex
+	refs int // goroutines holding or waiting for rw. guarded by KeyedMutex.mu
+}
+
+// Lock locks key for writing, blocking while another goroutine holds it.
+func (km *KeyedMutex[K]) Lock(key K) {
+	km.acquire(key).rw.Lock()
+}
+
+// TryLock locks key for writing if it's available right away and returns whether it did.
+func (km *KeyedMutex[K]) TryLock(key K) bool {
+	e := km.acquire(key)
+	if !e.rw.TryLock() {
+		km.release(key, e)
+		return false
+	}
+	return true
+}
+
+// LockCtx is same as Lock() but gives up when ctx is done. It returns nil if key was locked, else ctx.Err().
+func (km *KeyedMutex[K]) LockCtx(ctx context.Context, key K) error {
+	e := km.acquire(key)
+	if err := e.rw.LockCtx(ctx); err != nil {
+		km.release(key, e)
+		return err
+	}
+	return nil
+}
+
+// Unlock unlocks key for writing. It panics if key isn't locked for writing.
+func (km *KeyedMutex[K]) Unlock(key K) {
+	e := km.get(key, "Unlock")
+	e.rw.Unlock()
+	km.release(key, e)
+}
+
+// RLock locks key for reading, blocking while a writer holds it or waits for it.
+func (km *KeyedMutex[K]) RLock(key K) {
+	km.acquire(key).rw.RLock()
+}
+
+// TryRLock locks key for reading if it's available right away and returns whether it did.
+func (km *KeyedMutex[K]) TryRLock(key K) bool {
+	e := km.acquire(key)
+	if !e.rw.TryRLock() {
+		km.release(key, e)
+		return false
+	}
+	return true
+}
+
+// RLockCtx is same as RLock() but gives up when ctx is done. It returns nil if key was locked, else ctx.Err().
+func (km *KeyedMutex[K]) RLockCtx(ctx context.Context, key K) error {
+	e := km.acquire(key)
+	if err := e.rw.RLockCtx(ctx); err != nil {
+		km.release(key, e)
+		return err
+	}
+	return nil
+}
+
+// RUnlock undoes a single RLock() of key. It panics if key isn't locked for reading.
+func (km *KeyedMutex[K]) RUnlock(key K) {
+	e := km.get(key, "RUnlock")
+	e.rw.RUnlock()
+	km.release(key, e)
+}
+
+// Len returns the number of keys which are held or waited for.
+func (km *KeyedMutex[K]) Len() int {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	return len(km.m)
+}
+
+// acquire returns the entry of key, creating it if needed, and takes a reference on it.
+func (km *KeyedMutex[K]) acquire(key K) *keyedMutexEntry {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	e, ok := km.m[key]
+	if !ok {
+		if km.m == nil {
+			km.m = make(map[K]*keyedMutexEntry)
+		}
+		e = &keyedMutexEntry{}
+		km.m[key] = e
+		if deadlockDetection() {
+			nameLock(&e.rw, fmt.Sprintf("KeyedMutex key %v", key))
+		}
+	}
+	e.refs++
+	return e
+}
+
+// get returns the entry of key, which must exist as the caller holds it. op names the caller for the panic otherwise.
+func (km *KeyedMutex[K]) get(key K, op string) *keyedMutexEntry {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	e, ok := km.m[key]
+	if !ok {
+		panic("sync: " + op + " of unlocked KeyedMutex key")
+	}
+	return e
+}
+
+// release drops a reference on the entry of key, freeing it when it was the last one.
+func (km *KeyedMutex[K]) release(key K, e *keyedMutexEntry) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	e.refs--
+	if e.refs == 0 {
+		delete(km.m, key)
+		nameLock(&e.rw, "")
+	}
+}
@@ -0,0 +1,64 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var km KeyedMutex[string]
+	assert.Panics(t, func() { km.Unlock("a") })
+
+	// the map is only read concurrently, each counter is guarded by the lock of its key
+	counters := map[string]*int{"a": new(int), "b": new(int)}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for key := range counters {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					km.Lock(key)
+					*counters[key]++
+					km.Unlock(key)
+				}
+			}(key)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, 500, *counters["a"])
+	assert.Equal(t, 500, *counters["b"])
+
+	// uncontended keys are freed
+	assert.Equal(t, 0, km.Len())
+}
+
+func TestKeyedMutexIndependentKeys(t *testing.T) {
+	var km KeyedMutex[int]
+	km.Lock(1)
+	assert.Equal(t, true, km.TryLock(2))
+	assert.Equal(t, false, km.TryLock(1))
+	assert.Equal(t, false, km.TryRLock(1))
+	assert.Equal(t, 2, km.Len())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, km.LockCtx(ctx, 1))
+	assert.Equal(t, context.DeadlineExceeded, km.RLockCtx(ctx, 2))
+	km.Unlock(1)
+	km.Unlock(2)
+	assert.Equal(t, 0, km.Len())
+
+	km.RLock(3)
+	assert.Equal(t, true, km.TryRLock(3))
+	assert.Equal(t, nil, km.RLockCtx(context.Background(), 3))
+	assert.Equal(t, 1, km.Len())
+	km.RUnlock(3)
+	km.RUnlock(3)
+	km.RUnlock(3)
+	assert.Equal(t, 0, km.Len())
+}
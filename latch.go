@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Latch is a count down latch: it's created with a count, and goroutines waiting on it are released once
+// CountDown() has been called that many times, e.g. to proceed once N workers are ready. It's a Once which
+// becomes DONE on the last CountDown, so Wait() behaves same as Once.Done. A Latch can't be reset.
+// Clients should use NewLatch to create objects. A Latch must not be copied after first use.
+type Latch struct {
+	noCopy noCopy
+	count  int64
+	o      *Once
+}
+
+// NewLatch returns a Latch which is released after n calls to CountDown(). A Latch with a count of 0 is released
+// right away. An error is returned if n is negative.
+func NewLatch(n int) (*Latch, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("count can't be negative, got %d", n)
+	}
+	o, err := NewDefaultOnce(func() bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	l := &Latch{count: int64(n), o: o}
+	if n == 0 {
+		o.Do()
+	}
+	return l, nil
+}
+
+// CountDown decrements the count, releasing the waiters when it gets to zero. Calls after that are no-op.
+func (l *Latch) CountDown() {
+	for {
+		c := atomic.LoadInt64(&l.count)
+		if c == 0 {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&l.count, c, c-1) {
+			if c == 1 {
+				l.o.Do()
+			}
+			return
+		}
+	}
+}
+
+// Count returns the number of CountDown() calls still needed to release the waiters.
+func (l *Latch) Count() int {
+	return int(atomic.LoadInt64(&l.count))
+}
+
+// Wait returns if the count got to zero. If block = true, it blocks till the count gets to zero or the Latch is closed,
+// same as Once.Done.
+func (l *Latch) Wait(block bool) bool {
+	return l.o.Done(block)
+}
+
+// WaitCtx blocks till the count gets to zero and returns nil. It returns ErrClosed if the Latch is closed first,
+// and ctx.Err() if ctx is done first.
+func (l *Latch) WaitCtx(ctx context.Context) error {
+	if l.o.DoneContext(ctx) {
+		return nil
+	}
+	if l.o.Closed() {
+		return ErrClosed
+	}
+	return ctx.Err()
+}
+
+// DoneChan returns a channel which is closed when the count gets to zero or the Latch is closed.
+func (l *Latch) DoneChan() <-chan struct{} {
+	return l.o.DoneChan()
+}
+
+// Close releases all goroutines blocked on the Latch without the count getting to zero. Wait() returns false for them.
+func (l *Latch) Close() {
+	l.o.Close()
+}
@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatch(t *testing.T) {
+	_, err := NewLatch(-1)
+	assert.NotEqual(t, err, nil)
+
+	l, err := NewLatch(0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, l.Wait(true))
+
+	const n = 5
+	l, err = NewLatch(n)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, l.Wait(false))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, true, l.Wait(true))
+			assert.Equal(t, 0, l.Count())
+		}()
+	}
+	for i := 0; i < n; i++ {
+		go l.CountDown()
+	}
+	wg.Wait()
+	<-l.DoneChan()
+
+	l.CountDown()
+	assert.Equal(t, 0, l.Count())
+}
+
+func TestLatchWaitCtxAndClose(t *testing.T) {
+	l, err := NewLatch(1)
+	assert.Equal(t, err, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.WaitCtx(ctx))
+
+	go func() { time.Sleep(time.Millisecond * 2); l.Close() }()
+	assert.Equal(t, false, l.Wait(true))
+	assert.Equal(t, ErrClosed, l.WaitCtx(context.Background()))
+
+	l, err = NewLatch(1)
+	assert.Equal(t, err, nil)
+	l.CountDown()
+	assert.Equal(t, nil, l.WaitCtx(context.Background()))
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotStarted is returned by Lifecycle.Stop when Start() hasn't been called.
+var ErrNotStarted = errors.New("not started")
+
+// Lifecycle runs the start hooks of a service once and its stop hooks once, only after the start. It combines two
+// Onces, one per phase, so concurrent and repeated calls of a phase wait for its first run and get the same result.
+// Clients should use NewLifecycle to create objects.
+type Lifecycle struct {
+	start *Once
+	stop  *Once
+
+	mu         sync.Mutex
+	startHooks []func(ctx context.Context) error // guarded by mu
+	stopHooks  []func(ctx context.Context) error // guarded by mu
+	starting   bool                              // Start() was called. guarded by mu
+	stopping   bool                              // Stop() was called after Start(). guarded by mu
+
+	startErr error // written by the start run, read once start is DONE
+	stopErr  error // written by the stop run, read once stop is DONE
+}
+
+// NewLifecycle returns a Lifecycle with no hooks.
+func NewLifecycle() *Lifecycle {
+	// lazyDone = true, so a phase becomes DONE only after its result is stored
+	start, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	stop, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	return &Lifecycle{start: start, stop: stop}
+}
+
+// OnStart adds a hook run by Start(), after the hooks added before it. It returns ErrClosed if Start() was called
+// already, in which case the hook is never run.
+func (l *Lifecycle) OnStart(fn func(ctx context.Context) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.starting {
+		return ErrClosed
+	}
+	l.startHooks = append(l.startHooks, fn)
+	return nil
+}
+
+// OnStop adds a hook run by Stop(), before the hooks added before it. It returns ErrClosed if Stop() was called
+// already, in which case the hook is never run.
+func (l *Lifecycle) OnStop(fn func(ctx context.Context) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping {
+		return ErrClosed
+	}
+	l.stopHooks = append(l.stopHooks, fn)
+	return nil
+}
+
+// Start runs the start hooks in registration order, stopping at the first one which fails, and returns its error.
+// A panic in a hook is recovered and returned as a *PanicError. Only the first call runs the hooks. Other calls
+// wait for it and return the same result, or ctx.Err() if their ctx is done first.
+// The stop hooks aren't run when a start hook fails: call Stop() to release what the start hooks before it acquired.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	first := !l.starting
+	l.starting = true
+	hooks := l.startHooks
+	l.mu.Unlock()
+
+	// the others only wait, as DoAlso would block them till the hooks return whatever their ctx
+	if first {
+		l.start.DoAlso(func() bool {
+			for _, h := range hooks {
+				if l.startErr = runHook(ctx, h); l.startErr != nil {
+					break
+				}
+			}
+			return true
+		})
+	}
+	return l.WaitStarted(ctx)
+}
+
+// Stop runs the stop hooks in reverse registration order, even if some fail, and returns their errors combined
+// with errors.Join. It returns ErrNotStarted if Start() hasn't been called, and waits for Start() to complete
+// if it's running. Only the first call after Start() runs the hooks. Other calls wait for it and return the same
+// result, or ctx.Err() if their ctx is done first.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.starting {
+		l.mu.Unlock()
+		return ErrNotStarted
+	}
+	l.mu.Unlock()
+	if !l.start.DoneContext(ctx) {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	first := !l.stopping
+	l.stopping = true
+	hooks := l.stopHooks
+	l.mu.Unlock()
+
+	if first {
+		l.stop.DoAlso(func() bool {
+			var errs []error
+			for i := len(hooks) - 1; i >= 0; i-- {
+				errs = append(errs, runHook(ctx, hooks[i]))
+			}
+			l.stopErr = errors.Join(errs...)
+			return true
+		})
+	}
+	return l.WaitStopped(ctx)
+}
+
+// Running returns whether Start() has completed successfully and Stop() hasn't been called. It never blocks.
+func (l *Lifecycle) Running() bool {
+	l.mu.Lock()
+	stopping := l.stopping
+	l.mu.Unlock()
+	return !stopping && l.start.Done(false) && l.startErr == nil
+}
+
+// Stopped returns whether Stop() has completed. It never blocks.
+func (l *Lifecycle) Stopped() bool {
+	return l.stop.Done(false)
+}
+
+// WaitStarted blocks till Start() has completed and returns its result, or ctx.Err() if ctx is done first.
+func (l *Lifecycle) WaitStarted(ctx context.Context) error {
+	if !l.start.DoneContext(ctx) {
+		return ctx.Err()
+	}
+	return l.startErr
+}
+
+// WaitStopped blocks till Stop() has completed and returns its result, or ctx.Err() if ctx is done first.
+func (l *Lifecycle) WaitStopped(ctx context.Context) error {
+	if !l.stop.DoneContext(ctx) {
+		return ctx.Err()
+	}
+	return l.stopErr
+}
+
+// runHook calls h, returning a panic in it as a *PanicError.
+func runHook(ctx context.Context, h func(ctx context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = newPanicError(p)
+		}
+	}()
+	return h(ctx)
+}
@@ -0,0 +1,136 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	l := NewLifecycle()
+	var order []string
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	l.OnStart(hook("start db"))
+	l.OnStart(hook("start server"))
+	l.OnStop(hook("stop db"))
+	l.OnStop(hook("stop server"))
+
+	assert.Equal(t, ErrNotStarted, l.Stop(context.Background()))
+	assert.Equal(t, false, l.Running())
+
+	assert.Equal(t, nil, l.Start(context.Background()))
+	assert.Equal(t, nil, l.Start(context.Background()))
+	assert.Equal(t, true, l.Running())
+	assert.Equal(t, ErrClosed, l.OnStart(hook("late")))
+
+	assert.Equal(t, nil, l.Stop(context.Background()))
+	assert.Equal(t, nil, l.Stop(context.Background()))
+	assert.Equal(t, false, l.Running())
+	assert.Equal(t, true, l.Stopped())
+	assert.Equal(t, ErrClosed, l.OnStop(hook("late")))
+	assert.Equal(t, []string{"start db", "start server", "stop server", "stop db"}, order)
+}
+
+func TestLifecycleStartFails(t *testing.T) {
+	l := NewLifecycle()
+	errStart := errors.New("start failed")
+	ran := false
+	l.OnStart(func(context.Context) error { return errStart })
+	l.OnStart(func(context.Context) error {
+		ran = true
+		return nil
+	})
+	l.OnStop(func(context.Context) error { panic("boom") })
+
+	assert.Equal(t, errStart, l.Start(context.Background()))
+	assert.Equal(t, false, ran)
+	assert.Equal(t, false, l.Running())
+
+	var pe *PanicError
+	assert.Equal(t, true, errors.As(l.Stop(context.Background()), &pe))
+}
+
+func TestLifecycleWaits(t *testing.T) {
+	l := NewLifecycle()
+	release := make(chan struct{})
+	l.OnStart(func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l.WaitStarted(ctx))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, nil, l.Start(context.Background()))
+	}()
+	go func() {
+		defer wg.Done()
+		// waits for the start, then stops
+		for started := false; !started; time.Sleep(time.Millisecond) {
+			l.mu.Lock()
+			started = l.starting
+			l.mu.Unlock()
+		}
+		assert.Equal(t, nil, l.Stop(context.Background()))
+	}()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, false, l.Stopped())
+	close(release)
+	wg.Wait()
+	assert.Equal(t, nil, l.WaitStopped(context.Background()))
+	assert.Equal(t, true, l.Stopped())
+}
+
+func TestLifecycleOtherCallersDeadline(t *testing.T) {
+	l := NewLifecycle()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	l.OnStart(func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	stopRelease := make(chan struct{})
+	stopStarted := make(chan struct{})
+	l.OnStop(func(context.Context) error {
+		close(stopStarted)
+		<-stopRelease
+		return nil
+	})
+	expiring := func() context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	first := make(chan error)
+	go func() { first <- l.Start(context.Background()) }()
+	<-started
+	// the hook being run doesn't hold back the callers whose ctx expires
+	assert.Equal(t, context.DeadlineExceeded, l.Start(expiring()))
+	assert.Equal(t, context.DeadlineExceeded, l.Stop(expiring()))
+	close(release)
+	assert.Equal(t, nil, <-first)
+
+	go func() { first <- l.Stop(context.Background()) }()
+	<-stopStarted
+	assert.Equal(t, context.DeadlineExceeded, l.Stop(expiring()))
+	assert.Equal(t, false, l.Stopped())
+	close(stopRelease)
+	assert.Equal(t, nil, <-first)
+	assert.Equal(t, nil, l.Stop(context.Background()))
+}
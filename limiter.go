@@ -0,0 +1,189 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket rate limiter: the bucket holds up to burst tokens and is refilled at rate tokens
+// per second, each event taking one token. Allow() is the non-blocking way to take a token and Wait() the
+// blocking one. Clients should use NewLimiter to create objects.
+type Limiter struct {
+	clock  Clock
+	mu     sync.Mutex
+	rate   float64   // tokens per second. guarded by mu
+	burst  int       // guarded by mu
+	tokens float64   // tokens at last, negative when taken in advance by Reserve(). guarded by mu
+	last   time.Time // time tokens was last updated. guarded by mu
+}
+
+// Reservation is a token taken in advance by Limiter.Reserve, which can be used after Delay().
+type Reservation struct {
+	l        *Limiter
+	at       time.Time // when the token is available
+	canceled bool      // guarded by l.mu
+}
+
+// NewLimiter returns a Limiter allowing rate events per second with bursts of up to burst events. The bucket
+// starts full. An error is returned if rate or burst isn't positive.
+func NewLimiter(rate float64, burst int) (*Limiter, error) {
+	return NewLimiterWithClock(RealClock{}, rate, burst)
+}
+
+// NewLimiterWithClock is same as NewLimiter but the refill, the waits and the check of ctx deadlines are timed by
+// clock.
+func NewLimiterWithClock(clock Clock, rate float64, burst int) (*Limiter, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("rate needs to be positive, got %v", rate)
+	}
+	if burst <= 0 {
+		return nil, fmt.Errorf("burst needs to be positive, got %d", burst)
+	}
+	return &Limiter{clock: clock, rate: rate, burst: burst, tokens: float64(burst), last: clock.Now()}, nil
+}
+
+// Allow takes a token if one is available right away, and returns whether it did. It never blocks.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Reserve takes a token, in advance if none is available yet, and returns a Reservation telling when it can
+// be used. The caller should wait for Reservation.Delay() before the event, or Cancel() the Reservation.
+func (l *Limiter) Reserve() *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.advance(now)
+	l.tokens--
+	r := &Reservation{l: l, at: now}
+	if l.tokens < 0 {
+		r.at = now.Add(l.durationFor(-l.tokens))
+	}
+	return r
+}
+
+// Wait blocks till a token is available and takes it, or till ctx is done. It returns nil once the token is taken
+// and ctx.Err() if ctx is done first. An error is returned right away, without taking a token, if ctx has a deadline
+// before the token would be available.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.clock.Now()) < delay {
+		r.Cancel()
+		return fmt.Errorf("waiting %v for a token would exceed the context deadline", delay)
+	}
+	t := l.clock.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// SetRate changes the rate to rate tokens per second. The tokens accumulated at the old rate are kept.
+// SetRate panics if rate isn't positive.
+func (l *Limiter) SetRate(rate float64) {
+	if rate <= 0 {
+		panic("sync: limiter rate needs to be positive")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	l.rate = rate
+}
+
+// SetBurst changes the size of the bucket to burst tokens, dropping the tokens above it.
+// SetBurst panics if burst isn't positive.
+func (l *Limiter) SetBurst(burst int) {
+	if burst <= 0 {
+		panic("sync: limiter burst needs to be positive")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	l.burst = burst
+	if l.tokens > float64(burst) {
+		l.tokens = float64(burst)
+	}
+}
+
+// Rate returns the current rate in tokens per second.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Burst returns the current size of the bucket.
+func (l *Limiter) Burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.burst
+}
+
+// Tokens returns the number of tokens available right now, negative if tokens were reserved in advance.
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	return l.tokens
+}
+
+// advance refills the bucket for the time elapsed till now. Must be called holding mu.
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+		l.last = now
+	}
+}
+
+// durationFor returns the time to refill tokens at the current rate. Must be called holding mu.
+func (l *Limiter) durationFor(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// Delay returns how long to wait before the reserved token can be used, 0 if it can be used right away.
+func (r *Reservation) Delay() time.Duration {
+	if d := r.at.Sub(r.l.clock.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel gives the token back to the Limiter, so that others can take it, if it isn't available yet.
+// Cancel is idempotent.
+func (r *Reservation) Cancel() {
+	r.l.mu.Lock()
+	defer r.l.mu.Unlock()
+	now := r.l.clock.Now()
+	if r.canceled || !now.Before(r.at) {
+		return
+	}
+	r.canceled = true
+	r.l.advance(now)
+	r.l.tokens++
+	if r.l.tokens > float64(r.l.burst) {
+		r.l.tokens = float64(r.l.burst)
+	}
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(0, 1)
+	assert.NotEqual(t, err, nil)
+	_, err = NewLimiter(1, 0)
+	assert.NotEqual(t, err, nil)
+
+	l, err := NewLimiter(1, 1)
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { l.SetRate(-1) })
+	assert.Panics(t, func() { l.SetBurst(0) })
+}
+
+func TestLimiterAllow(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	l, _ := NewLimiterWithClock(c, 100, 3)
+	assert.Equal(t, true, l.Allow())
+	assert.Equal(t, true, l.Allow())
+	assert.Equal(t, true, l.Allow())
+	assert.Equal(t, false, l.Allow())
+
+	c.Advance(9 * time.Millisecond)
+	assert.Equal(t, false, l.Allow())
+	c.Advance(2 * time.Millisecond)
+	assert.Equal(t, true, l.Allow())
+
+	c.Advance(time.Second)
+	assert.Equal(t, 3.0, l.Tokens())
+	l.SetBurst(1)
+	assert.Equal(t, 1, l.Burst())
+	assert.Equal(t, 1.0, l.Tokens())
+}
+
+func TestLimiterReserve(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	l, _ := NewLimiterWithClock(c, 10, 1)
+	r := l.Reserve()
+	assert.Equal(t, time.Duration(0), r.Delay())
+
+	r = l.Reserve()
+	assert.Equal(t, 100*time.Millisecond, r.Delay())
+	assert.Equal(t, -1.0, l.Tokens())
+	c.Advance(40 * time.Millisecond)
+	assert.Equal(t, 60*time.Millisecond, r.Delay())
+
+	r.Cancel()
+	r.Cancel()
+	assert.InDelta(t, 0.4, l.Tokens(), 1e-9)
+
+	// a reservation can't be canceled once usable
+	r = l.Reserve()
+	c.Advance(time.Second)
+	r.Cancel()
+	assert.Equal(t, 1.0, l.Tokens())
+}
+
+func TestLimiterWait(t *testing.T) {
+	c := NewManualClock(time.Now())
+	l, _ := NewLimiterWithClock(c, 50, 1)
+	assert.Equal(t, nil, l.Wait(context.Background()))
+
+	done := make(chan error)
+	go func() { done <- l.Wait(context.Background()) }()
+	waitTimers(c, 1)
+	c.Advance(20*time.Millisecond - time.Nanosecond)
+	select {
+	case <-done:
+		t.Fatal("Wait returned before the token was available")
+	default:
+	}
+	c.Advance(time.Nanosecond)
+	assert.Equal(t, nil, <-done)
+
+	// the token wouldn't be available before the deadline, as per the clock
+	ctx, cancel := context.WithDeadline(context.Background(), c.Now().Add(time.Millisecond))
+	defer cancel()
+	assert.NotEqual(t, nil, l.Wait(ctx))
+	assert.Equal(t, 0, c.Timers())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, l.Wait(ctx))
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	l, _ := NewLimiterWithClock(c, 1, 1)
+	assert.Equal(t, true, l.Allow())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- l.Wait(ctx) }()
+	waitTimers(c, 1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	// the reserved token was given back
+	assert.Equal(t, 0.0, l.Tokens())
+}
+
+func TestLimiterSetRate(t *testing.T) {
+	c := NewManualClock(clockEpoch)
+	l, _ := NewLimiterWithClock(c, 1, 1)
+	assert.Equal(t, true, l.Allow())
+	c.Advance(500 * time.Millisecond)
+	// the tokens accumulated at the old rate are kept
+	l.SetRate(1000)
+	assert.Equal(t, 1000.0, l.Rate())
+	assert.Equal(t, 0.5, l.Tokens())
+	c.Advance(time.Millisecond)
+	assert.Equal(t, true, l.Allow())
+}
@@ -0,0 +1,206 @@
+package sync
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// mapShards is the number of shards of a Map. Writes to keys in different shards don't contend.
+const mapShards = 32
+
+// Map is a type safe concurrent map, an alternative to the Map of golang's sync package with the operations it lacks
+// e.g. Len, Keys and LoadOrCompute. It's split in shards, each with its own lock, so that writes to different keys
+// mostly don't contend, which suits write heavy workloads better than sync.Map.
+// The zero value is ready to use. A Map must not be copied after first use.
+type Map[K comparable, V any] struct {
+	init    sync.Once
+	seed    maphash.Seed
+	shards  [mapShards]mapShard[K, V]
+	flights SingleFlight[K, V] // LoadOrCompute calls in progress
+}
+
+type mapShard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V // guarded by mu
+}
+
+// shard returns the shard of key.
+func (m *Map[K, V]) shard(key K) *mapShard[K, V] {
+	m.init.Do(func() {
+		m.seed = maphash.MakeSeed()
+	})
+	return &m.shards[maphash.Comparable(m.seed, key)%mapShards]
+}
+
+// Load returns the value stored for key, and whether there is one.
+func (m *Map[K, V]) Load(key K) (value V, ok bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok = s.m[key]
+	return value, ok
+}
+
+// Store sets the value for key.
+func (m *Map[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, value)
+}
+
+// LoadOrStore returns the value stored for key and true if there is one. Otherwise it stores value for key
+// and returns it with false.
+func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actual, loaded = s.m[key]; loaded {
+		return actual, true
+	}
+	s.set(key, value)
+	return value, false
+}
+
+// LoadOrCompute returns the value stored for key and true if there is one. Otherwise it stores the value returned
+// by compute for key and returns it with false. compute is called at most once per missing key: concurrent callers
+// missing the same key wait for the one calling compute and get its value with true. compute is called without
+// holding the shard lock, so it can use the map. If compute panics, nothing is stored and the panic is propagated
+// as a *PanicError to all the callers waiting for it.
+func (m *Map[K, V]) LoadOrCompute(key K, compute func() V) (actual V, loaded bool) {
+	if actual, loaded = m.Load(key); loaded {
+		return actual, true
+	}
+	computed := false
+	actual, err, _ := m.flights.Do(key, func() (V, error) {
+		// a call which completed between the Load above and joining the flights has stored the value already
+		if v, ok := m.Load(key); ok {
+			return v, nil
+		}
+		v, loaded := m.LoadOrStore(key, compute())
+		computed = !loaded
+		return v, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return actual, !computed
+}
+
+// LoadAndDelete deletes the value for key, returning the previous value and whether there was one.
+func (m *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, loaded = s.m[key]; loaded {
+		delete(s.m, key)
+	}
+	return value, loaded
+}
+
+// Delete deletes the value for key.
+func (m *Map[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap stores value for key and returns the previous value, and whether there was one.
+func (m *Map[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, loaded = s.m[key]
+	s.set(key, value)
+	return previous, loaded
+}
+
+// CompareAndSwap stores new for key if the value stored for key is equal to old, and returns whether it did.
+// Same as with sync.Map, the values are compared with ==, which panics if V isn't comparable at run time.
+func (m *Map[K, V]) CompareAndSwap(key K, old, new V) bool {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.m[key]; !ok || any(cur) != any(old) {
+		return false
+	}
+	s.m[key] = new
+	return true
+}
+
+// CompareAndDelete deletes the value for key if it's equal to old, and returns whether it did.
+// The values are compared same as by CompareAndSwap.
+func (m *Map[K, V]) CompareAndDelete(key K, old V) bool {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.m[key]; !ok || any(cur) != any(old) {
+		return false
+	}
+	delete(s.m, key)
+	return true
+}
+
+// Range calls f for each key and value in the map, till f returns false. Each shard is copied before calling f
+// for its entries, so f can modify the map. Same as with sync.Map, Range doesn't see a consistent snapshot:
+// changes made while ranging may or may not be seen.
+func (m *Map[K, V]) Range(f func(key K, value V) bool) {
+	type entry struct {
+		key   K
+		value V
+	}
+	var entries []entry
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		entries = entries[:0]
+		for k, v := range s.m {
+			entries = append(entries, entry{k, v})
+		}
+		s.mu.RUnlock()
+		for _, e := range entries {
+			if !f(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of keys in the map. It's exact only if the map isn't being modified concurrently.
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Keys returns the keys of the map, in no particular order. Same as Range, it isn't a consistent snapshot
+// if the map is modified concurrently.
+func (m *Map[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	m.Range(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Clear deletes all the keys.
+func (m *Map[K, V]) Clear() {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		s.m = nil
+		s.mu.Unlock()
+	}
+}
+
+// set stores value for key. Must be called holding mu.
+func (s *mapShard[K, V]) set(key K, value V) {
+	if s.m == nil {
+		s.m = make(map[K]V)
+	}
+	s.m[key] = value
+}
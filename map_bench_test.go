@@ -0,0 +1,50 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+)
+
+const benchmarkMapKeys = 1024
+
+// benchmarkMap runs loads and stores in parallel on benchmarkMapKeys keys, with one in writeEvery
+// operations being a write and the others reads.
+func benchmarkMap(b *testing.B, writeEvery int, load func(int), store func(int)) {
+	for i := 0; i < benchmarkMapKeys; i++ {
+		store(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := i % benchmarkMapKeys
+			if i%writeEvery == 0 {
+				store(key)
+			} else {
+				load(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMap(b *testing.B) {
+	for _, bm := range []struct {
+		name       string
+		writeEvery int
+	}{
+		{"ReadMostly", 100},
+		{"Balanced", 2},
+		{"WriteOnly", 1},
+	} {
+		b.Run(bm.name+"/Map", func(b *testing.B) {
+			var m Map[int, int]
+			benchmarkMap(b, bm.writeEvery, func(k int) { m.Load(k) }, func(k int) { m.Store(k, k) })
+		})
+		b.Run(bm.name+"/sync.Map", func(b *testing.B) {
+			var m sync.Map
+			benchmarkMap(b, bm.writeEvery, func(k int) { m.Load(k) }, func(k int) { m.Store(k, k) })
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package sync
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	var m Map[string, int]
+	_, ok := m.Load("a")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, 0, m.Len())
+
+	m.Store("a", 1)
+	v, ok := m.Load("a")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, v)
+
+	v, loaded := m.LoadOrStore("a", 2)
+	assert.Equal(t, true, loaded)
+	assert.Equal(t, 1, v)
+	v, loaded = m.LoadOrStore("b", 2)
+	assert.Equal(t, false, loaded)
+	assert.Equal(t, 2, v)
+
+	v, loaded = m.Swap("b", 3)
+	assert.Equal(t, true, loaded)
+	assert.Equal(t, 2, v)
+
+	assert.Equal(t, false, m.CompareAndSwap("b", 2, 4))
+	assert.Equal(t, true, m.CompareAndSwap("b", 3, 4))
+	assert.Equal(t, false, m.CompareAndSwap("c", 0, 1))
+	assert.Equal(t, false, m.CompareAndDelete("b", 3))
+	assert.Equal(t, true, m.CompareAndDelete("b", 4))
+
+	m.Store("c", 5)
+	keys := m.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "c"}, keys)
+	assert.Equal(t, 2, m.Len())
+
+	v, loaded = m.LoadAndDelete("c")
+	assert.Equal(t, true, loaded)
+	assert.Equal(t, 5, v)
+	m.Delete("a")
+	assert.Equal(t, 0, m.Len())
+
+	m.Store("d", 1)
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestMapLoadOrCompute(t *testing.T) {
+	var m Map[int, string]
+	calls := 0
+	compute := func() string {
+		calls++
+		return "x"
+	}
+	v, loaded := m.LoadOrCompute(1, compute)
+	assert.Equal(t, false, loaded)
+	assert.Equal(t, "x", v)
+	v, loaded = m.LoadOrCompute(1, compute)
+	assert.Equal(t, true, loaded)
+	assert.Equal(t, "x", v)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMapLoadOrComputeConcurrentMiss(t *testing.T) {
+	var m Map[int, int]
+	var calls, computed int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, loaded := m.LoadOrCompute(1, func() int {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 42
+			})
+			assert.Equal(t, 42, v)
+			if !loaded {
+				atomic.AddInt32(&computed, 1)
+			}
+		}()
+	}
+	// let the goroutines pile up on the missing key
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&computed))
+}
+
+func TestMapLoadOrComputePanic(t *testing.T) {
+	var m Map[int, int]
+	func() {
+		defer func() {
+			_, ok := recover().(*PanicError)
+			assert.Equal(t, true, ok)
+		}()
+		m.LoadOrCompute(1, func() int { panic("boom") })
+	}()
+	_, ok := m.Load(1)
+	assert.Equal(t, false, ok)
+
+	v, loaded := m.LoadOrCompute(1, func() int { return 1 })
+	assert.Equal(t, false, loaded)
+	assert.Equal(t, 1, v)
+}
+
+func TestMapRange(t *testing.T) {
+	var m Map[int, int]
+	for i := 0; i < 100; i++ {
+		m.Store(i, i*i)
+	}
+	seen := 0
+	m.Range(func(k, v int) bool {
+		assert.Equal(t, k*k, v)
+		// modifying the map from f doesn't deadlock
+		m.Delete(k)
+		seen++
+		return true
+	})
+	assert.Equal(t, 100, seen)
+	assert.Equal(t, 0, m.Len())
+
+	m.Store(1, 1)
+	m.Store(2, 2)
+	seen = 0
+	m.Range(func(int, int) bool {
+		seen++
+		return false
+	})
+	assert.Equal(t, 1, seen)
+}
+
+func TestMapConcurrent(t *testing.T) {
+	var m Map[int, int]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				for {
+					v, _ := m.LoadOrStore(i, 0)
+					if m.CompareAndSwap(i, v, v+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, m.Len())
+	m.Range(func(_, v int) bool {
+		assert.Equal(t, 8, v)
+		return true
+	})
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"reflect"
+)
+
+// MultiWait waits on several primitives at once, without a goroutine per primitive.
+// Clients should use NewMultiWait to create objects.
+type MultiWait struct {
+	ws []DoneChanner
+}
+
+// NewMultiWait returns a MultiWait for ws. The order of ws is the one of the indexes returned by WaitAny.
+func NewMultiWait(ws ...DoneChanner) *MultiWait {
+	return &MultiWait{ws: append([]DoneChanner(nil), ws...)}
+}
+
+// WaitAll blocks till all the primitives are complete and returns nil, or returns ctx.Err() if ctx is done first.
+// It returns nil right away for a MultiWait with no primitives.
+func (m *MultiWait) WaitAll(ctx context.Context) error {
+	for _, w := range m.ws {
+		select {
+		case <-w.DoneChan():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WaitAny blocks till one of the primitives is complete and returns its index, or returns -1 and ctx.Err() if ctx
+// is done first. If several are complete, any of them may be returned. With no primitives, it blocks till ctx is
+// done.
+func (m *MultiWait) WaitAny(ctx context.Context) (int, error) {
+	cases := make([]reflect.SelectCase, len(m.ws)+1)
+	for i, w := range m.ws {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.DoneChan())}
+	}
+	cases[len(m.ws)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	i, _, _ := reflect.Select(cases)
+	if i == len(m.ws) {
+		return -1, ctx.Err()
+	}
+	return i, nil
+}
+
+// Completed returns the indexes of the primitives which are complete, without blocking.
+func (m *MultiWait) Completed() []int {
+	var done []int
+	for i, w := range m.ws {
+		select {
+		case <-w.DoneChan():
+			done = append(done, i)
+		default:
+		}
+	}
+	return done
+}
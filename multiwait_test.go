@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiWait(t *testing.T) {
+	o, _ := NewDefaultOnce(returnTrue)
+	l, _ := NewLatch(1)
+	e := NewEvent()
+	f := NewFuture[int]()
+	m := NewMultiWait(o, l, e, f)
+	assert.Equal(t, []int(nil), m.Completed())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	i, err := m.WaitAny(ctx)
+	assert.Equal(t, -1, i)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		e.Set()
+	}()
+	i, err = m.WaitAny(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, i)
+	assert.Equal(t, []int{2}, m.Completed())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.WaitAll(ctx))
+
+	go func() {
+		o.Do()
+		l.CountDown()
+		f.Complete(1, nil)
+	}()
+	assert.Equal(t, nil, m.WaitAll(context.Background()))
+	assert.Equal(t, []int{0, 1, 2, 3}, m.Completed())
+}
+
+func TestMultiWaitEmpty(t *testing.T) {
+	m := NewMultiWait()
+	assert.Equal(t, nil, m.WaitAll(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	i, err := m.WaitAny(ctx)
+	assert.Equal(t, -1, i)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,271 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Mutex is a mutual exclusion lock like the Mutex of golang's sync package, with lock acquisition which can be
+// bounded by a context or a timeout, e.g. in request handlers. Its zero value is an unlocked mutex.
+// A Mutex must not be copied after first use.
+type Mutex struct {
+	init sync.Once
+	ch   chan struct{} // holds a token while the mutex is locked
+}
+
+// lockCh returns the channel holding the token, creating it on first use.
+func (m *Mutex) lockCh() chan struct{} {
+	m.init.Do(func() {
+		m.ch = make(chan struct{}, 1)
+	})
+	return m.ch
+}
+
+// Lock locks m, blocking till it's available.
+func (m *Mutex) Lock() {
+	if deadlockDetection() {
+		m.LockCtx(context.Background())
+		return
+	}
+	m.lockCh() <- struct{}{}
+}
+
+// TryLock locks m if it's available right away and returns whether it did.
+func (m *Mutex) TryLock() bool {
+	select {
+	case m.lockCh() <- struct{}{}:
+		lockAcquired(m)
+		return true
+	default:
+		return false
+	}
+}
+
+// LockCtx locks m, blocking till it's available or ctx is done. It returns nil if m was locked, else ctx.Err().
+func (m *Mutex) LockCtx(ctx context.Context) error {
+	if m.TryLock() {
+		return nil
+	}
+	done := lockWaiting(m)
+	select {
+	case m.lockCh() <- struct{}{}:
+		done(true)
+		return nil
+	case <-ctx.Done():
+		done(false)
+		return ctx.Err()
+	}
+}
+
+// TryLockTimeout locks m, blocking for at most timeout. It returns whether m was locked.
+func (m *Mutex) TryLockTimeout(timeout time.Duration) bool {
+	return m.TryLockTimeoutWithClock(RealClock{}, timeout)
+}
+
+// TryLockTimeoutWithClock is same as TryLockTimeout() but the timeout is measured by clock.
+func (m *Mutex) TryLockTimeoutWithClock(clock Clock, timeout time.Duration) bool {
+	ctx, cancel := timeoutContext(clock, timeout)
+	defer cancel()
+	return m.LockCtx(ctx) == nil
+}
+
+// Unlock unlocks m. Same as with the Mutex of golang's sync package, m can be unlocked by another goroutine
+// than the one which locked it. Unlock panics if m isn't locked.
+func (m *Mutex) Unlock() {
+	lockReleased(m)
+	select {
+	case <-m.lockCh():
+	default:
+		panic("sync: unlock of unlocked Mutex")
+	}
+}
+
+// RWMutex is a reader/writer mutual exclusion lock like the RWMutex of golang's sync package, with lock acquisition
+// which can be bounded by a context or a timeout. Same as there, a blocked writer keeps new readers out, so writers
+// aren't starved. Its zero value is an unlocked mutex. An RWMutex must not be copied after first use.
+type RWMutex struct {
+	mu             sync.Mutex
+	readers        int           // guarded by mu
+	writer         bool          // guarded by mu
+	writersWaiting int           // guarded by mu
+	wakeCh         chan struct{} // closed to wake up the blocked goroutines on every release. guarded by mu
+}
+
+// Lock locks rw for writing, blocking till no reader or writer holds it.
+func (rw *RWMutex) Lock() {
+	rw.LockCtx(context.Background())
+}
+
+// TryLock locks rw for writing if it's available right away and returns whether it did.
+func (rw *RWMutex) TryLock() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.writer || rw.readers > 0 {
+		return false
+	}
+	rw.writer = true
+	lockAcquired(rw)
+	return true
+}
+
+// LockCtx is same as Lock() but gives up when ctx is done. It returns nil if rw was locked, else ctx.Err().
+func (rw *RWMutex) LockCtx(ctx context.Context) error {
+	if deadlockDetection() {
+		if rw.TryLock() {
+			return nil
+		}
+		done := lockWaiting(rw)
+		err := rw.lockCtx(ctx)
+		done(err == nil)
+		return err
+	}
+	return rw.lockCtx(ctx)
+}
+
+// lockCtx implements LockCtx() without the deadlock detection.
+func (rw *RWMutex) lockCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	waiting := false
+	for rw.writer || rw.readers > 0 {
+		if !waiting {
+			// keeps new readers out till this writer is done
+			rw.writersWaiting++
+			waiting = true
+		}
+		if err := rw.wait(ctx); err != nil {
+			rw.writersWaiting--
+			// readers held back by this writer may go ahead now
+			rw.wakeAll()
+			return err
+		}
+	}
+	if waiting {
+		rw.writersWaiting--
+	}
+	rw.writer = true
+	return nil
+}
+
+// TryLockTimeout locks rw for writing, blocking for at most timeout. It returns whether rw was locked.
+func (rw *RWMutex) TryLockTimeout(timeout time.Duration) bool {
+	return rw.TryLockTimeoutWithClock(RealClock{}, timeout)
+}
+
+// TryLockTimeoutWithClock is same as TryLockTimeout() but the timeout is measured by clock.
+func (rw *RWMutex) TryLockTimeoutWithClock(clock Clock, timeout time.Duration) bool {
+	ctx, cancel := timeoutContext(clock, timeout)
+	defer cancel()
+	return rw.LockCtx(ctx) == nil
+}
+
+// Unlock unlocks rw for writing. It panics if rw isn't locked for writing.
+func (rw *RWMutex) Unlock() {
+	lockReleased(rw)
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if !rw.writer {
+		panic("sync: Unlock of unlocked RWMutex")
+	}
+	rw.writer = false
+	rw.wakeAll()
+}
+
+// RLock locks rw for reading, blocking while a writer holds it or is waiting for it.
+func (rw *RWMutex) RLock() {
+	rw.RLockCtx(context.Background())
+}
+
+// TryRLock locks rw for reading if it's available right away and returns whether it did.
+func (rw *RWMutex) TryRLock() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.writer || rw.writersWaiting > 0 {
+		return false
+	}
+	rw.readers++
+	lockAcquired(rw)
+	return true
+}
+
+// RLockCtx is same as RLock() but gives up when ctx is done. It returns nil if rw was locked, else ctx.Err().
+func (rw *RWMutex) RLockCtx(ctx context.Context) error {
+	if deadlockDetection() {
+		if rw.TryRLock() {
+			return nil
+		}
+		done := lockWaiting(rw)
+		err := rw.rlockCtx(ctx)
+		done(err == nil)
+		return err
+	}
+	return rw.rlockCtx(ctx)
+}
+
+// rlockCtx implements RLockCtx() without the deadlock detection.
+func (rw *RWMutex) rlockCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for rw.writer || rw.writersWaiting > 0 {
+		if err := rw.wait(ctx); err != nil {
+			return err
+		}
+	}
+	rw.readers++
+	return nil
+}
+
+// TryRLockTimeout locks rw for reading, blocking for at most timeout. It returns whether rw was locked.
+func (rw *RWMutex) TryRLockTimeout(timeout time.Duration) bool {
+	return rw.TryRLockTimeoutWithClock(RealClock{}, timeout)
+}
+
+// TryRLockTimeoutWithClock is same as TryRLockTimeout() but the timeout is measured by clock.
+func (rw *RWMutex) TryRLockTimeoutWithClock(clock Clock, timeout time.Duration) bool {
+	ctx, cancel := timeoutContext(clock, timeout)
+	defer cancel()
+	return rw.RLockCtx(ctx) == nil
+}
+
+// RUnlock undoes a single RLock() call. It panics if rw isn't locked for reading.
+func (rw *RWMutex) RUnlock() {
+	lockReleased(rw)
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.readers == 0 {
+		panic("sync: RUnlock of unlocked RWMutex")
+	}
+	rw.readers--
+	if rw.readers == 0 {
+		rw.wakeAll()
+	}
+}
+
+// wait releases mu till the next release of rw or ctx is done, in which case it returns ctx.Err().
+// Must be called holding mu, which is held again when it returns.
+func (rw *RWMutex) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rw.wakeCh == nil {
+		rw.wakeCh = make(chan struct{})
+	}
+	ch := rw.wakeCh
+	rw.mu.Unlock()
+	defer rw.mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wakeAll wakes up the goroutines blocked in wait(). Must be called holding mu.
+func (rw *RWMutex) wakeAll() {
+	if rw.wakeCh != nil {
+		close(rw.wakeCh)
+		rw.wakeCh = nil
+	}
+}
@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutex(t *testing.T) {
+	var m Mutex
+	assert.Panics(t, func() { m.Unlock() })
+
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Lock()
+				counter++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, counter)
+}
+
+func TestMutexBounded(t *testing.T) {
+	var m Mutex
+	assert.Equal(t, true, m.TryLock())
+	assert.Equal(t, false, m.TryLock())
+	c := NewManualClock(clockEpoch)
+	assert.Equal(t, false, advanceWhile(c, time.Hour, func() bool { return m.TryLockTimeoutWithClock(c, time.Hour) }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, m.LockCtx(ctx))
+
+	go m.Unlock()
+	assert.Equal(t, nil, m.LockCtx(context.Background()))
+	m.Unlock()
+	assert.Equal(t, true, m.TryLockTimeoutWithClock(c, time.Hour))
+	m.Unlock()
+	assert.Equal(t, 0, c.Timers())
+}
+
+func TestRWMutex(t *testing.T) {
+	var rw RWMutex
+	assert.Panics(t, func() { rw.Unlock() })
+	assert.Panics(t, func() { rw.RUnlock() })
+
+	// readers share the lock, writers exclude everybody
+	assert.Equal(t, true, rw.TryRLock())
+	assert.Equal(t, true, rw.TryRLock())
+	assert.Equal(t, false, rw.TryLock())
+	rw.RUnlock()
+	rw.RUnlock()
+	assert.Equal(t, true, rw.TryLock())
+	assert.Equal(t, false, rw.TryRLock())
+	c := NewManualClock(clockEpoch)
+	assert.Equal(t, false, advanceWhile(c, time.Hour, func() bool { return rw.TryRLockTimeoutWithClock(c, time.Hour) }))
+	rw.Unlock()
+
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rw.Lock()
+				counter++
+				rw.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rw.RLock()
+				_ = counter
+				rw.RUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 500, counter)
+}
+
+func TestRWMutexWriterPreference(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+
+	locked := make(chan struct{})
+	go func() { rw.Lock(); close(locked) }()
+	for {
+		rw.mu.Lock()
+		waiting := rw.writersWaiting
+		rw.mu.Unlock()
+		if waiting == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// a waiting writer keeps new readers out
+	assert.Equal(t, false, rw.TryRLock())
+	rw.RUnlock()
+	<-locked
+	rw.Unlock()
+}
+
+func TestRWMutexCtx(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, rw.LockCtx(ctx))
+	c := NewManualClock(clockEpoch)
+	assert.Equal(t, false, advanceWhile(c, time.Hour, func() bool { return rw.TryLockTimeoutWithClock(c, time.Hour) }))
+
+	// the writer which gave up doesn't keep readers out
+	assert.Equal(t, nil, rw.RLockCtx(context.Background()))
+	rw.RUnlock()
+	rw.RUnlock()
+	assert.Equal(t, nil, rw.LockCtx(context.Background()))
+	rw.Unlock()
+}
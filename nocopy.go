@@ -0,0 +1,30 @@
+package sync
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// noCopy is embedded in the types which must not be copied after first use and don't hold a lock already, so that
+// the copylocks check of go vet flags their copies. It takes no space. See https://golang.org/issues/8005.
+type noCopy struct{}
+
+// Lock is a no-op used by the copylocks check of go vet.
+func (*noCopy) Lock() {}
+
+// Unlock is a no-op used by the copylocks check of go vet.
+func (*noCopy) Unlock() {}
+
+// copyChecker detects at runtime the copies go vet can't see, e.g. through reflection or unsafe, same as the one of
+// sync.Cond: it holds its own address from first use, which a copy doesn't match.
+type copyChecker uintptr
+
+// check panics if c has been copied since its first check.
+func (c *copyChecker) check(typ string) {
+	self := uintptr(unsafe.Pointer(c))
+	if atomic.LoadUintptr((*uintptr)(c)) != self &&
+		!atomic.CompareAndSwapUintptr((*uintptr)(c), 0, self) &&
+		atomic.LoadUintptr((*uintptr)(c)) != self {
+		panic("sync: " + typ + " is copied")
+	}
+}
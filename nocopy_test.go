@@ -0,0 +1,40 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// copyOf copies *v the way go vet can't see.
+func copyOf[T any](v *T) *T {
+	c := reflect.New(reflect.TypeOf(v).Elem())
+	c.Elem().Set(reflect.ValueOf(v).Elem())
+	return c.Interface().(*T)
+}
+
+func TestOnceCopied(t *testing.T) {
+	o, err := NewDefaultOnce(func() bool { return true })
+	assert.Equal(t, err, nil)
+
+	// not used yet
+	c := copyOf(o)
+	assert.Equal(t, true, c.Do())
+
+	assert.Equal(t, true, o.Do())
+	c = copyOf(o)
+	assert.PanicsWithValue(t, "sync: Once is copied", func() { c.Do() })
+	assert.PanicsWithValue(t, "sync: Once is copied", func() { c.Done(false) })
+	assert.PanicsWithValue(t, "sync: Once is copied", func() { c.Reset() })
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestCompactOnceCopied(t *testing.T) {
+	o, err := NewOnceCompact(func() bool { return true })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	c := copyOf(o)
+	assert.PanicsWithValue(t, "sync: CompactOnce is copied", func() { c.Do() })
+	assert.PanicsWithValue(t, "sync: CompactOnce is copied", func() { c.Done(true) })
+}
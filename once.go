@@ -1,9 +1,12 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // The simplest use of Once looks like this
@@ -33,16 +36,62 @@ const (
 )
 
 // Once defines the stateful type. Clients should use NewOnce to create objects
+// A Once must not be copied after first use: go vet flags the copies, and a copy it can't see panics when used.
 type Once struct {
+	// done and unblock are always accessed atomically, even while holding mu,
+	// since the fast paths read them without holding mu.
 	mu             sync.Mutex
-	fs             []FuncType
+	fs             []FuncType    // guarded by mu. Any code reading or replacing fs after construction must hold mu
+	errFs          []ErrFuncType // the functions given to NewOnceErr, wrapped as the first len(errFs) of fs. guarded by mu
 	done           uint32
 	lazyDone       bool
 	suppressPanic  bool
 	doneFromVerify bool
 	verify         VerifyType
-	unblockCond    *sync.Cond // used to signal any blocking client about change of state
+	progress       func(completed, total int)
+	stateMu        sync.Mutex    // the state lock, short lived. Never held while executing the function/s
+	wakeCh         chan struct{} // closed to wake up the goroutines blocked in waitFor. guarded by stateMu
 	unblock        uint32
+	waiters        int32 // number of goroutines blocked in wait()
+	duration       int64 // time.Duration of the last execution of the function/s
+	running        uint32
+	started        uint32 // set once an execution has started in the current generation
+	failed         uint32 // set if the latest execution in the current generation failed
+	count          uint64 // number of executions started since creation
+	calls          uint64 // number of calls to Do() and its variants since creation
+	blocked        uint64 // number of those calls which had to wait for mu
+	released       uint64 // number of goroutines which returned from waitFor after blocking in it
+	strict         bool
+	notify         []chan<- struct{}          // channels registered with DoneNotify. guarded by stateMu
+	subs           map[chan struct{}]struct{} // channels returned by Subscribe. guarded by stateMu
+	onDone         []func()                   // callbacks registered with OnDone. guarded by stateMu
+	doneCh         chan struct{}              // returned by DoneChan. guarded by stateMu
+	doneChClosed   bool                       // guarded by stateMu
+	resetAfter     time.Duration
+	resetTimer     Timer  // pending automatic reset. guarded by mu
+	clock          Clock  // nil for RealClock
+	gen            uint64 // incremented by every reset. written atomically while holding mu
+	parallel       bool
+	wrapper        func(next func())
+	retryAttempts  int
+	retryBackoff   func(attempt int) time.Duration
+	retryOnPanic   bool
+	fifo           bool
+	failure        failureMode
+	panicHandler   PanicHandler
+	lockName       string
+	lockObserver   LockObserver
+	lockAcquired   time.Time     // when the goroutine executing the function/s acquired mu. guarded by mu
+	lockWait       time.Duration // guarded by mu
+	lockContended  bool          // guarded by mu
+	fifoHead       *fifoWaiter   // waiters queued in arrival order. guarded by stateMu
+	fifoTail       *fifoWaiter   // guarded by stateMu
+	err            error         // guarded by stateMu
+	winner         interface{}   // guarded by stateMu
+	hasWinner      bool          // guarded by stateMu
+	value          interface{}   // guarded by stateMu
+	hasValue       bool          // guarded by stateMu
+	checker        copyChecker   // mu makes go vet flag the copies of a Once, checker the ones it can't see
 }
 
 // NewDefaultOnce is a wrapper over NewOnce. It returns a Once object with the default options.
@@ -50,27 +99,62 @@ func NewDefaultOnce(f FuncType, fs ...FuncType) (*Once, error) {
 	return NewOnce(false, false, VerifyNone, f, fs...)
 }
 
+// NewOnceTTL returns a Once which re-arms itself ttl after becoming DONE, so that the next Do() executes the function/s
+// again, e.g. to refresh a cached credential at most once per period. It's same as NewOnceWithOptions with
+// WithLazyDone(true) and WithResetAfter(ttl): callers of Do() during an execution wait for it to finish,
+// and the ttl is measured from the end of the execution. An error is returned if ttl isn't positive.
+func NewOnceTTL(ttl time.Duration, f FuncType, fs ...FuncType) (*Once, error) {
+	return NewOnceTTLWithOptions(ttl, append([]FuncType{f}, fs...))
+}
+
+// NewOnceTTLWithOptions is same as NewOnceTTL with opts applied after WithLazyDone(true) and WithResetAfter(ttl),
+// e.g. WithClock to measure the ttl with a ManualClock in tests.
+func NewOnceTTLWithOptions(ttl time.Duration, fs []FuncType, opts ...Option) (*Once, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl needs to be positive, got %v", ttl)
+	}
+	return NewOnceWithOptions(fs, append([]Option{WithLazyDone(true), WithResetAfter(ttl)}, opts...)...)
+}
+
 // NewOnce returns a new Once object with the give options. Atleast one function needs to be given.
 // In case of muliple functions, they are executed in the order they were passed.
 //
 // Below paramether combinations will raise error:
 //   - lazyDone = true; verify = VerifyAll / VerifyFirstRunAll / VerifyFirstExit
+//   - any of the functions is nil
 func NewOnce(lazyDone bool, suppressPanic bool, verify VerifyType, f FuncType, fs ...FuncType) (*Once, error) {
-	fs = append([]FuncType{f}, fs...)
+	return NewOnceWithOptions(append([]FuncType{f}, fs...), WithLazyDone(lazyDone), WithSuppressPanic(suppressPanic), WithVerify(verify))
+}
 
-	if lazyDone == false && verify != VerifyNone {
-		return nil, fmt.Errorf("lazyDone needs to true when using verify=%s or set verify=%s", verify, VerifyNone)
+// NewOnceWithOptions returns a new Once for the functions fs configured using opts. Atleast one function needs to be given.
+// Without any options the Once behaves same as the one returned by NewDefaultOnce.
+// Option combinations which are invalid for NewOnce also raise error here.
+func NewOnceWithOptions(fs []FuncType, opts ...Option) (*Once, error) {
+	if len(fs) == 0 {
+		return nil, fmt.Errorf("atleast one function needs to be given")
 	}
 
-	return &Once{
-		mu:            sync.Mutex{},
-		fs:            fs,
-		lazyDone:      lazyDone,
-		suppressPanic: suppressPanic,
-		verify:        verify,
-		unblockCond:   sync.NewCond(&sync.Mutex{}),
-		unblock:       0,
-	}, nil
+	d := &Once{
+		mu:      sync.Mutex{},
+		fs:      append([]FuncType{}, fs...),
+		unblock: 0,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	for i, f := range fs {
+		if f == nil {
+			d.misuse(fmt.Sprintf("function at index %d is nil", i))
+			return nil, fmt.Errorf("function at index %d is nil", i)
+		}
+	}
+
+	if d.lazyDone == false && d.verify != VerifyNone {
+		return nil, fmt.Errorf("lazyDone needs to true when using verify=%s or set verify=%s", d.verify, VerifyNone)
+	}
+
+	return d, nil
 }
 
 // Do function is used to execute the function/s once.
@@ -80,73 +164,423 @@ func NewOnce(lazyDone bool, suppressPanic bool, verify VerifyType, f FuncType, f
 // This helps identify which call to Do() was successful if there are mulitple and the client needs to know which one worked.
 // A lot of what Do ends up doing will depend on the different options used while crating Once.
 //
-//   lazyDone bool
+//	lazyDone bool
+//
 // when lazyDone = false, Do() firt sets the state as DONE and then goes on to execute the function/s.
 // If lazyDone = false, Do() first calls function and then sets DONE. Whether DONE gets set is also dependent on Verify options.
 //
-//   suppressPanic bool
+//	suppressPanic bool
+//
 // if suppressPanic = true, any panics from the code executed by function/s will be suppressed.
 // When panics are suppressed, successive Do() calls may or may not trigger the function/s even if the first exection did panic.
 // That is dependent on the value of Verify used. See the unit test cases for all possible cases
-func (d *Once) Do() (res bool) {
+func (d *Once) Do() bool {
+	return d.doCall(doArgs{})
+}
+
+// doCall is shared by Do() and its blocking variants, which differ only in args: it counts the call in Stats
+// and executes the function/s unless Once is DONE or closed.
+func (d *Once) doCall(args doArgs) bool {
+	atomic.AddUint64(&d.calls, 1)
+	return d.do(args)
+}
+
+// do is doCall without counting the call in Stats.
+func (d *Once) do(args doArgs) bool {
+	// fast path: if already done or closed, no need to lock
+	if d.fastDone() {
+		return false
+	}
+
+	// slow path: lock and call function once
+	d.lockDo()
+	defer d.unlockDo()
+	return d.doSlow(args)
+}
+
+// TryDo is the non-blocking version of Do(). If another goroutine is executing the function/s,
+// TryDo doesn't wait for it to finish and returns false immediately.
+// Otherwise it behaves same as Do().
+func (d *Once) TryDo() bool {
+	atomic.AddUint64(&d.calls, 1)
+	// fast path: if already done or closed, no need to lock
+	if d.fastDone() {
+		return false
+	}
+
+	if !d.mu.TryLock() {
+		return false
+	}
+	d.lockedDo(time.Time{}, false)
+	defer d.unlockDo()
+	return d.doSlow(doArgs{})
+}
+
+// DoOrWait executes the function/s if no other goroutine has, and returns true. Otherwise it blocks till
+// the execution in progress, if any, completes and returns false. Unlike Do(), whose fast path returns right away
+// once DONE is set, even if the function/s are still executing when lazyDone = false, a goroutine getting false
+// from DoOrWait always observes the side effects of the completed execution.
+// TryDo() is its counterpart which never blocks.
+func (d *Once) DoOrWait() bool {
+	if d.Do() {
+		return true
+	}
+
+	d.waitExecution()
+	return false
+}
+
+// waitExecution blocks till the execution in progress, if any, completes. The executing goroutine holds mu till
+// it's done, so this waits for it and orders its writes before ours. Not counted in Stats as the Do() preceding it
+// already counted the call if it blocked.
+func (d *Once) waitExecution() {
+	if d.executed() {
+		return
+	}
+	d.mu.Lock()
+	d.mu.Unlock()
+}
+
+// executed reports whether the Once is DONE with no execution in progress, without locking mu. running is cleared
+// after DONE is set, so the atomic loads seeing both order the writes of the completed execution before ours.
+func (d *Once) executed() bool {
+	return atomic.LoadUint32(&d.done) == 1 && atomic.LoadUint32(&d.running) == 0
+}
+
+// DoE is same as Do() but also returns Err() i.e. the error recorded by the latest execution of the function/s.
+// A caller which didn't execute the function/s gets the error of the execution it waited for, if any.
+func (d *Once) DoE() (bool, error) {
+	res := d.Do()
+	return res, d.Err()
+}
+
+// DoAlso is same as Do() but if this caller executes the function/s, the extra functions are executed after them
+// as part of the same execution, e.g. to append a step needed by one call site. Only the extras of the caller which
+// executes are used: callers which don't execute get false and the extras they give are ignored.
+// The extras count as functions of the Once for the verify and progress options, but are not kept by the Once,
+// so they aren't executed again after Reset(). A nil extra panics when executed.
+func (d *Once) DoAlso(extra ...FuncType) bool {
+	return d.doCall(doArgs{extra: extra})
+}
+
+// DoIf is same as Do() but the function/s are executed only if pred returns true.
+// pred is evaluated by the goroutine which would execute the function/s, after it has acquired the Once.
+// If pred returns false, the Once stays as it is i.e. DONE is not set, and DoIf returns false.
+// So a later Do() or DoIf() can still execute the function/s.
+// Only the predicate of the goroutine which gets to execute matters. Goroutines blocked behind it
+// evaluate their own pred only if the Once is still not DONE when they acquire it.
+func (d *Once) DoIf(pred func() bool) bool {
+	return d.doCall(doArgs{pred: pred})
+}
+
+// DoAs is same as Do() but if this call returns true, token is recorded as the winner of the current generation.
+// The token can be any value identifying the caller and is later available from Winner().
+// This lets frameworks assign follow-up responsibilities, e.g. cleanup, to the caller which did the initialization.
+func (d *Once) DoAs(token interface{}) bool {
+	return d.doCall(doArgs{token: token})
+}
+
+// Winner returns the token of the caller which got true from Do() or its variants in the current generation,
+// and true. The token is nil if the winner didn't use DoAs(). It returns nil and false if there is no winner
+// i.e. no call has returned true since creation or the last Reset().
+func (d *Once) Winner() (interface{}, bool) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.winner, d.hasWinner
+}
+
+// setWinner records token as the winner of the current generation.
+func (d *Once) setWinner(token interface{}) {
+	d.stateMu.Lock()
+	d.winner, d.hasWinner = token, true
+	d.stateMu.Unlock()
+}
+
+// DoContext behaves like Do() but the caller stops waiting when ctx is done, whether it's blocked on an in-flight
+// execution of another goroutine or its own call started the execution. In that case it returns false with ctx.Err().
+// If the function/s panic and suppressPanic = false, the panic is raised in the caller if it is still waiting.
+//
+// Nothing is rolled back when ctx is done first: the execution continues in the background and leaves the Once
+// in the state it would leave it in for Do(), e.g. DONE, or failed as reported by State(). A panic which no caller
+// is left to receive is recorded in Err() as a *PanicError. For another caller to be able to retry after a timeout,
+// the timed out execution must fail and the failure must not set DONE, e.g. by using WithStickyFailure(false).
+func (d *Once) DoContext(ctx context.Context) (bool, error) {
+	atomic.AddUint64(&d.calls, 1)
+	// fast path: if already done or closed, no need to wait
+	if d.fastDone() {
+		return false, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	type result struct {
+		res bool
+		p   interface{}
+	}
+	ch := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		var r result
+		defer func() {
+			if p := recover(); p != nil {
+				r.p = p
+			}
+			select {
+			case ch <- r:
+			case <-abandoned:
+				if r.p != nil {
+					d.setErr(newPanicError(r.p))
+				}
+			}
+		}()
+		r.res = d.do(doArgs{})
+	}()
+
+	select {
+	case r := <-ch:
+		if r.p != nil {
+			panic(r.p)
+		}
+		return r.res, nil
+	case <-ctx.Done():
+		close(abandoned)
+		return false, ctx.Err()
+	}
+}
+
+// DoWithTimeout is same as DoContext with a context which is done after timeout, as measured by the clock of
+// WithClock.
+func (d *Once) DoWithTimeout(timeout time.Duration) (bool, error) {
+	ctx, cancel := timeoutContext(clockOrReal(d.clock), timeout)
+	defer cancel()
+	return d.DoContext(ctx)
+}
+
+// fastDone reports if Once is DONE or closed, in which case Do() and its variants return without locking.
+func (d *Once) fastDone() bool {
+	d.checker.check("Once")
+	if atomic.LoadUint32(&d.unblock) == 1 {
+		d.misuse("Do called on a closed Once")
+		return true
+	}
+	return atomic.LoadUint32(&d.done) == 1
+}
+
+// lockDo acquires mu for executing the function/s, counting the callers which have to wait for it in Stats.
+func (d *Once) lockDo() {
+	if d.mu.TryLock() {
+		d.lockedDo(time.Time{}, false)
+		return
+	}
+	atomic.AddUint64(&d.blocked, 1)
+	var start time.Time
+	if d.lockObserver != nil {
+		start = time.Now()
+	}
+	d.mu.Lock()
+	d.lockedDo(start, true)
+}
+
+// lockedDo records the acquisition of mu for the LockObserver, if any. start is when the caller started waiting.
+// Must be called holding mu.
+func (d *Once) lockedDo(start time.Time, contended bool) {
+	if d.lockObserver == nil {
+		return
+	}
+	d.lockAcquired = time.Now()
+	d.lockWait = 0
+	if contended {
+		d.lockWait = d.lockAcquired.Sub(start)
+	}
+	d.lockContended = contended
+}
+
+// unlockDo releases mu acquired with lockDo, reporting the acquisition to the LockObserver, if any.
+func (d *Once) unlockDo() {
+	if d.lockObserver == nil {
+		d.mu.Unlock()
+		return
+	}
+	e := LockEvent{Name: d.lockName, Wait: d.lockWait, Hold: time.Since(d.lockAcquired), Contended: d.lockContended}
+	d.mu.Unlock()
+	d.lockObserver(e)
+}
+
+// misuse panics with msg if the Once is in strict mode, else it does nothing.
+func (d *Once) misuse(msg string) {
+	if d.strict {
+		panic("sync: " + msg)
+	}
+}
+
+// DoAndClose calls Do() and then Close() as a single operation. No other Do() can run in between the two.
+// It returns what Do() returned i.e. whether this caller was the one to execute the function/s.
+// The Once always ends in closed state even if the function/s panic. So subsequent calls to Do() are no-op
+// and Done(true) returns immediately till Reset() is called.
+// This is useful for the "initialize exactly once, then seal" pattern.
+func (d *Once) DoAndClose() bool {
+	atomic.AddUint64(&d.calls, 1)
+	d.checker.check("Once")
+	d.lockDo()
+	defer d.unlockDo()
+	defer d.close()
+	return d.doSlow(doArgs{})
+}
+
+// doArgs are the arguments of the different variants of Do().
+type doArgs struct {
+	pred  func() bool // DoIf: execute only if pred returns true
+	token interface{} // DoAs: recorded as the winner
+	extra []FuncType  // DoAlso: executed after the functions of the Once
+}
+
+// doSlow executes the function/s unless Once is already DONE or closed, or args.pred is non-nil and returns false.
+// d.mu must be held by the caller.
+func (d *Once) doSlow(args doArgs) (res bool) {
 	res = false
-	// fast path: if already done, no need to lock
+	if atomic.LoadUint32(&d.unblock) == 1 {
+		d.misuse("Do called on a closed Once")
+		return false
+	}
 	if atomic.LoadUint32(&d.done) == 1 {
 		return false
 	}
 
 	if d.suppressPanic {
 		defer func() {
-			recover()
+			if p := recover(); p != nil {
+				d.setErr(d.recovered(p))
+			}
 		}()
 	}
-
-	// slow path: lock and call function once
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	if d.done == 1 {
+	if args.pred != nil && !args.pred() {
 		return false
 	}
 
+	defer func() {
+		if res {
+			d.setWinner(args.token)
+		}
+	}()
+
+	// Err() reports only the latest execution
+	d.setErr(nil)
+
 	// signal all waiting goroutines
-	defer d.unblockCond.Broadcast()
+	defer d.signal()
+
+	if d.resetAfter > 0 {
+		defer func() {
+			if atomic.LoadUint32(&d.done) == 1 {
+				d.scheduleReset()
+			}
+		}()
+	}
+
+	atomic.StoreUint32(&d.running, 1)
+	defer atomic.StoreUint32(&d.running, 0)
+
+	// completed stays false if the function/s panic. The outcome is recorded before running is cleared
+	// so that State() never reports a failed execution as DONE.
+	completed := false
+	atomic.StoreUint32(&d.failed, 0)
+	defer func() {
+		if completed && res && d.Err() == nil {
+			return
+		}
+		atomic.StoreUint32(&d.failed, 1)
+		switch d.failure {
+		case failureSticky:
+			atomic.StoreUint32(&d.done, 1)
+		case failureRetryable:
+			atomic.StoreUint32(&d.done, 0)
+		}
+	}()
+
+	atomic.StoreUint32(&d.started, 1)
+	atomic.AddUint64(&d.count, 1)
+	d.broadcast() // for WaitReady()
+
+	// measure the execution time. It's recorded even if the function/s panic
+	clock := clockOrReal(d.clock)
+	start := clock.Now()
+	defer func() {
+		atomic.StoreInt64(&d.duration, int64(clock.Now().Sub(start)))
+	}()
 
 	// check if done needs to be set before or after calling the function
 	if d.lazyDone == false {
 		atomic.StoreUint32(&d.done, 1)
 	}
 
-	if d.verify == VerifyAll {
+	// DoAlso: the extra functions are part of this execution only
+	fs := d.fs
+	if len(args.extra) > 0 {
+		fs = append(fs[:len(fs):len(fs)], args.extra...)
+	}
 
-		tempRes := true
-		for _, f := range d.fs {
-			tempRes = tempRes && f()
-		}
-		res = tempRes
+	// res is assigned as the functions execute, so it is meaningful even if a panic is suppressed
+	exec := func() {
+		if d.parallel {
 
-	} else if d.verify == VerifyFirstRunAll {
+			res = d.execParallel(fs)
 
-		for _, f := range d.fs {
-			res = f() || res // f() should be the first arg to || operator
-		}
+		} else if d.verify == VerifyAll {
 
-	} else if d.verify == VerifyFirstExit {
+			tempRes := true
+			for i := range fs {
+				tempRes = tempRes && d.call(fs, i)
+			}
+			res = tempRes
 
-		for _, f := range d.fs {
-			if f() {
-				res = true
-				break
+		} else if d.verify == VerifyFirstRunAll {
+
+			for i := range fs {
+				res = d.call(fs, i) || res // d.call() should be the first arg to || operator
 			}
-		}
 
-	} else {
+		} else if d.verify == VerifyFirstExit {
+
+			for i := range fs {
+				if d.call(fs, i) {
+					res = true
+					break
+				}
+			}
+
+		} else {
+
+			res = true
+			for i := range fs {
+				d.call(fs, i)
+			}
 
-		res = true
-		for _, f := range d.fs {
-			f()
 		}
+	}
+	execute := exec
+	if d.wrapper != nil {
+		execute = func() { d.wrapper(exec) }
+	}
 
+	// all attempts but the last are retried on failure. The last attempt behaves same as without retries.
+	attempt := 1
+	for ; attempt < d.retryAttempts; attempt++ {
+		res = false
+		d.setErr(nil)
+		if d.attempt(execute) && res {
+			break
+		}
+		if d.retryBackoff != nil {
+			<-clockOrReal(d.clock).After(d.retryBackoff(attempt))
+		}
 	}
+	if attempt >= d.retryAttempts {
+		res = false
+		d.setErr(nil)
+		execute()
+	}
+	completed = true
 
 	if d.lazyDone == true && res {
 		atomic.StoreUint32(&d.done, 1)
@@ -155,46 +589,576 @@ func (d *Once) Do() (res bool) {
 	return res
 }
 
+// attempt executes the function/s using execute and reports if that completed without a panic.
+// A panic is recovered and recorded for Err() only if WithRetryOnPanic was used, else it propagates.
+func (d *Once) attempt(execute func()) (ok bool) {
+	if d.retryOnPanic {
+		defer func() {
+			if p := recover(); p != nil {
+				d.setErr(d.recovered(p))
+				ok = false
+			}
+		}()
+	}
+	execute()
+	return true
+}
+
+// execParallel executes all the functions concurrently and waits for all of them to finish.
+// Each function runs with its own recover so a panic in one of them doesn't affect the others.
+// The panics are combined into a single error, which is added to the errors reported by the functions for Err()
+// if suppressPanic = true, and raised as a panic otherwise, only after all the functions have finished.
+// The results of the functions are combined based on verify. VerifyFirstExit behaves same as VerifyFirstRunAll
+// as no function can be skipped.
+func (d *Once) execParallel(fs []FuncType) bool {
+	type result struct {
+		res bool
+		err error
+	}
+
+	ch := make(chan result, len(fs))
+	for _, f := range fs {
+		go func(f FuncType) {
+			var r result
+			defer func() {
+				if p := recover(); p != nil {
+					r.err = newPanicError(p)
+				}
+				ch <- r
+			}()
+			r.res = f()
+		}(f)
+	}
+
+	allTrue, anyTrue := true, false
+	var errs []error
+	for i := range fs {
+		r := <-ch
+		allTrue = allTrue && r.res && r.err == nil
+		anyTrue = anyTrue || r.res
+		if r.err != nil {
+			errs = append(errs, r.err)
+			if d.suppressPanic && d.panicHandler != nil {
+				d.panicHandler(r.err.(*PanicError).Value)
+			}
+		}
+		if d.progress != nil {
+			d.progress(i+1, len(fs))
+		}
+	}
+
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		if !d.suppressPanic {
+			panic(err)
+		}
+		d.addErr(err)
+	}
+
+	switch d.verify {
+	case VerifyAll:
+		return allTrue
+	case VerifyFirstRunAll, VerifyFirstExit:
+		return anyTrue
+	default:
+		return true
+	}
+}
+
+// call executes fs[i], the i'th function of the execution, and reports the progress if WithProgress was used.
+func (d *Once) call(fs []FuncType, i int) bool {
+	res := fs[i]()
+	if d.progress != nil {
+		d.progress(i+1, len(fs))
+	}
+	return res
+}
+
 // Done returns if the Once is in DONE state. Calls to Done() are non-blocking.
 // Value used for lazyDone changes behavior in case of concurrent access.
 // If Done() if called concurrently with Do() it may return true even if Do() is still executing.
 //
 // Done(true) : the calling goroutines will block till the state becomes DONE or Close() is called explicitly to unblocak all goroutines
-//              returns true or false based on whether state is DONE or not.
+//
+//	returns true or false based on whether state is DONE or not.
+//
 // Done(false) : returns immediately and returns whether state is DONE or not.
 func (d *Once) Done(block bool) bool {
+	d.checker.check("Once")
 
 	// blocking behavior
 	if block {
-		for atomic.LoadUint32(&d.unblock) == 0 {
-			if atomic.LoadUint32(&d.done) == 1 {
-				break
+		d.wait(context.Background())
+	}
+
+	return atomic.LoadUint32(&d.done) == 1
+}
+
+// Wait blocks same as Done(true).
+func (d *Once) Wait() {
+	d.Done(true)
+}
+
+// WaitErr blocks same as Done(true) and tells why it returned: nil if the Once is DONE, or ErrClosed if it was
+// closed without becoming DONE. Unlike the false returned by Done(true), ErrClosed can't be confused with
+// the Once not being DONE yet.
+func (d *Once) WaitErr() error {
+	if d.Done(true) {
+		return nil
+	}
+	return ErrClosed
+}
+
+// Closed returns if Close() has been called since creation or the last Reset(). It never blocks.
+func (d *Once) Closed() bool {
+	return atomic.LoadUint32(&d.unblock) == 1
+}
+
+// DoneContext is same as Done(true) but gives up waiting when ctx is done, so it can't block forever if Do()
+// is never called and nobody calls Close(). It returns whether the state is DONE at the time it returns.
+func (d *Once) DoneContext(ctx context.Context) bool {
+	d.wait(ctx)
+	return atomic.LoadUint32(&d.done) == 1
+}
+
+// WaitReady blocks till a goroutine has started executing the function/s, or the Once is DONE or closed.
+// Unlike Done(true), which waits for the execution to complete, WaitReady returns as soon as the work is underway.
+// This lets a coordinator proceed once some goroutine has committed to the initialization.
+// It stays satisfied till Reset() is called, even if the execution finished without setting DONE.
+func (d *Once) WaitReady() {
+	d.waitFor(context.Background(), func() bool {
+		return atomic.LoadUint32(&d.started) == 1 || atomic.LoadUint32(&d.unblock) == 1 || atomic.LoadUint32(&d.done) == 1
+	})
+}
+
+// wait blocks till the Once is DONE or closed, or ctx is done.
+func (d *Once) wait(ctx context.Context) {
+	d.waitFor(ctx, func() bool {
+		return atomic.LoadUint32(&d.unblock) == 1 || atomic.LoadUint32(&d.done) == 1
+	})
+}
+
+// waitFor blocks till ready returns true or ctx is done, and returns the last result of ready.
+// ready is evaluated while holding stateMu and re-evaluated every time the waiting goroutines are woken up.
+// It's also evaluated once without holding stateMu, so it must only read state which is accessed atomically.
+func (d *Once) waitFor(ctx context.Context, ready func() bool) bool {
+	if d.fifo {
+		return d.waitForFIFO(ctx, ready)
+	}
+
+	// fast path: nothing to wait for
+	if ready() {
+		return true
+	}
+
+	// state is checked while holding stateMu, and the channel to wait on is taken under it too,
+	// so that a wake up between checking and waiting can't be missed
+	waited := false
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	for ctx.Err() == nil {
+		if ready() {
+			if waited {
+				atomic.AddUint64(&d.released, 1)
 			}
-			d.unblockCond.L.Lock()
-			d.unblockCond.Wait()
-			d.unblockCond.L.Unlock()
+			return true
+		}
+		if d.wakeCh == nil {
+			d.wakeCh = make(chan struct{})
 		}
+		wakeCh := d.wakeCh
+
+		atomic.AddInt32(&d.waiters, 1)
+		d.stateMu.Unlock()
+		select {
+		case <-wakeCh:
+		case <-ctx.Done():
+		}
+		d.stateMu.Lock()
+		atomic.AddInt32(&d.waiters, -1)
+		waited = true
 	}
+	return ready()
+}
 
-	return atomic.LoadUint32(&d.done) == 1
+// Err returns the error recorded by the latest execution of the function/s in the current generation,
+// or nil if there was none. Reset() clears it.
+// When suppressPanic = true, the suppressed panics are recorded as *PanicError, which match ErrPanicked with errors.Is.
+// In parallel mode all the panics are combined into the error, otherwise only the first panic is recorded
+// as no function is executed after that.
+func (d *Once) Err() error {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.err
+}
+
+// setErr records err as the error of the latest execution.
+func (d *Once) setErr(err error) {
+	d.stateMu.Lock()
+	d.err = err
+	d.stateMu.Unlock()
+}
+
+// Count returns the number of times the function/s have been executed since the Once was created.
+// It counts every execution, including the ones which didn't set DONE or panicked, and is not affected by Reset().
+// Being a uint64 it would wrap around to 0 after 2^64 executions, which is not a concern in practice.
+func (d *Once) Count() uint64 {
+	return atomic.LoadUint64(&d.count)
+}
+
+// Generation returns the number of times the Once has been reset, either by Reset() or automatically.
+// The functions are executed at most once per generation unless lazyDone = true and an execution didn't set DONE.
+// Being a uint64 it would wrap around to 0 after 2^64 resets, which is not a concern in practice.
+func (d *Once) Generation() uint64 {
+	return atomic.LoadUint64(&d.gen)
+}
+
+// Duration returns how long the last execution of the function/s took. It is zero till an execution finishes
+// and is cleared by Reset().
+func (d *Once) Duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&d.duration))
+}
+
+// Waiters returns the number of goroutines currently blocked waiting for the Once to be DONE or closed,
+// e.g. in Done(true). Waiters which gave up because of a context are not counted.
+func (d *Once) Waiters() int {
+	return int(atomic.LoadInt32(&d.waiters))
+}
+
+// StoreValue attaches v to the Once, so that the function/s can publish what they computed through the same Once
+// which gates readiness. It's meant to be called by the function/s or the winning goroutine, but can be called anytime.
+// A later call replaces the value. Reset() clears it.
+// If StoreValue is called by the function/s and DONE is set after they return i.e. lazyDone = true, any goroutine
+// which has observed DONE, e.g. through Done(true), also observes v in LoadValue().
+func (d *Once) StoreValue(v interface{}) {
+	d.stateMu.Lock()
+	d.value, d.hasValue = v, true
+	d.stateMu.Unlock()
+}
+
+// LoadValue returns the value stored with StoreValue and true, or nil and false if none is stored.
+func (d *Once) LoadValue() (interface{}, bool) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.value, d.hasValue
+}
+
+// ErrNotReady is returned by ReadinessProbe while the function/s haven't completed.
+var ErrNotReady = errors.New("once is not ready")
+
+// ReadinessProbe reports the readiness of the Once as an error, so that a probe handler can map it directly to
+// a response e.g. nil to 200 and any error to 503. It doesn't block, same as Done(false).
+// It returns Err() if the latest execution recorded an error, nil if the Once is DONE, ErrClosed if it was closed
+// without becoming DONE, and ErrNotReady otherwise i.e. while the function/s haven't been executed or are executing.
+func (d *Once) ReadinessProbe() error {
+	if err := d.Err(); err != nil {
+		return err
+	}
+	if atomic.LoadUint32(&d.done) == 1 {
+		return nil
+	}
+	if atomic.LoadUint32(&d.unblock) == 1 {
+		return ErrClosed
+	}
+	return ErrNotReady
+}
+
+// DoneNotify registers ch to be notified when the Once becomes DONE or is closed.
+// If that's already the case, ch is notified immediately. Channels stay registered across Reset(),
+// so they are notified again for every later execution which makes the Once DONE, similar to signal.Notify.
+//
+// Notifications are best-effort: the send on ch is non-blocking and dropped if ch isn't ready.
+// So ch should be buffered, a buffer of 1 is enough to never miss the latest notification.
+func (d *Once) DoneNotify(ch chan<- struct{}) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	d.notify = append(d.notify, ch)
+	if atomic.LoadUint32(&d.done) == 1 || atomic.LoadUint32(&d.unblock) == 1 {
+		notify(ch)
+	}
+}
+
+// Subscribe returns a channel which is closed when the Once becomes DONE or is closed, and a function to cancel
+// the subscription if the caller loses interest before that, so that nothing is left registered with a Once which
+// never completes. If the Once is already DONE or closed, the returned channel is already closed and cancel is a no-op.
+// Unlike DoneNotify, a subscription is for a single completion: it doesn't carry over Reset().
+func (d *Once) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{})
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	if atomic.LoadUint32(&d.done) == 1 || atomic.LoadUint32(&d.unblock) == 1 {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if d.subs == nil {
+		d.subs = make(map[chan struct{}]struct{})
+	}
+	d.subs[ch] = struct{}{}
+	return ch, func() {
+		d.stateMu.Lock()
+		delete(d.subs, ch)
+		d.stateMu.Unlock()
+	}
+}
+
+// DoneChan returns a channel which is closed when the Once becomes DONE or is closed, same as when Done(true) returns.
+// It's useful to select on the Once together with other channels e.g. timers or ctx.Done().
+// The channel is for the current generation: after Reset(), DoneChan returns a new channel.
+func (d *Once) DoneChan() <-chan struct{} {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	if d.doneCh == nil {
+		d.doneCh = make(chan struct{})
+	}
+	if atomic.LoadUint32(&d.done) == 1 || atomic.LoadUint32(&d.unblock) == 1 {
+		d.closeDoneCh()
+	}
+	return d.doneCh
+}
+
+// closeDoneCh closes the channel returned by DoneChan, if any. Must be called holding stateMu.
+func (d *Once) closeDoneCh() {
+	if d.doneCh != nil && !d.doneChClosed {
+		close(d.doneCh)
+		d.doneChClosed = true
+	}
+}
+
+// OnDone registers f to be called once, when the Once becomes DONE. If it's already DONE, f is called right away
+// by the caller of OnDone. Otherwise f is called by the goroutine which executed the function/s, after they return
+// and the waiting goroutines are woken up. That goroutine still holds the Once, so f must not call methods which
+// wait to acquire it, e.g. Reset(), and should hand off long running work to another goroutine.
+// With lazyDone = false, DONE is set before the function/s execute, so f registered meanwhile is called right away.
+// Closing the Once doesn't call f: it stays registered, e.g. for after Reset().
+func (d *Once) OnDone(f func()) {
+	d.stateMu.Lock()
+	if atomic.LoadUint32(&d.done) == 0 {
+		d.onDone = append(d.onDone, f)
+		d.stateMu.Unlock()
+		return
+	}
+	d.stateMu.Unlock()
+	f()
+}
+
+// notify does a non-blocking send on ch.
+func notify(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// signal wakes up all the waiting goroutines and, if Once is DONE or closed, notifies the channels
+// registered with DoneNotify and closes the ones returned by Subscribe. If Once is DONE, it then calls
+// the callbacks registered with OnDone.
+func (d *Once) signal() {
+	d.stateMu.Lock()
+	d.wakeAll()
+	if atomic.LoadUint32(&d.done) == 1 || atomic.LoadUint32(&d.unblock) == 1 {
+		for _, ch := range d.notify {
+			notify(ch)
+		}
+		for ch := range d.subs {
+			close(ch)
+		}
+		d.subs = nil
+		d.closeDoneCh()
+	}
+	var callbacks []func()
+	if atomic.LoadUint32(&d.done) == 1 {
+		callbacks, d.onDone = d.onDone, nil
+	}
+	d.stateMu.Unlock()
+
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// broadcast wakes up all goroutines blocked in waitFor() to re-evaluate what they are waiting for.
+func (d *Once) broadcast() {
+	d.stateMu.Lock()
+	d.wakeAll()
+	d.stateMu.Unlock()
+}
+
+// wakeAll is broadcast() for a caller holding stateMu. The goroutines which wait after it use a new channel.
+func (d *Once) wakeAll() {
+	if d.wakeCh != nil {
+		close(d.wakeCh)
+		d.wakeCh = nil
+	}
+	d.releaseFIFO()
+}
+
+// Clone returns a new Once with the same functions and options as this one but in a fresh state,
+// i.e. not DONE, not closed and with no execution history. The two Onces are independent of each other.
+// For a Once created with NewOnceErr, the errors of the clone's executions are recorded by the clone.
+func (d *Once) Clone() *Once {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := &Once{
+		mu:            sync.Mutex{},
+		fs:            append([]FuncType{}, d.fs...),
+		lazyDone:      d.lazyDone,
+		suppressPanic: d.suppressPanic,
+		verify:        d.verify,
+		progress:      d.progress,
+		strict:        d.strict,
+		resetAfter:    d.resetAfter,
+		parallel:      d.parallel,
+		wrapper:       d.wrapper,
+		retryAttempts: d.retryAttempts,
+		retryBackoff:  d.retryBackoff,
+		retryOnPanic:  d.retryOnPanic,
+		fifo:          d.fifo,
+		failure:       d.failure,
+		panicHandler:  d.panicHandler,
+		lockName:      d.lockName,
+		lockObserver:  d.lockObserver,
+		clock:         d.clock,
+		unblock:       0,
+	}
+	if d.errFs != nil {
+		// the wrappers of d record the errors on d, bind new ones to the clone
+		c.errFs = d.errFs
+		copy(c.fs, errFuncs(d.errFs, c.addErr))
+	}
+	return c
+}
+
+// AddFunc appends fs to the functions of the Once, for when not all of them are known while creating it.
+// It's allowed only while the Once is in NotStarted state i.e. before the first execution, or after Reset().
+// Otherwise an error is returned, as it is if any of the functions is nil. If the functions are executing,
+// AddFunc returns the error right away instead of waiting for them.
+func (d *Once) AddFunc(fs ...FuncType) error {
+	for i, f := range fs {
+		if f == nil {
+			d.misuse(fmt.Sprintf("function at index %d is nil", i))
+			return fmt.Errorf("function at index %d is nil", i)
+		}
+	}
+
+	if !d.mu.TryLock() {
+		if atomic.LoadUint32(&d.running) == 1 {
+			d.misuse("AddFunc called while the functions are running")
+			return fmt.Errorf("can't add functions while they are running")
+		}
+		d.mu.Lock()
+	}
+	defer d.mu.Unlock()
+
+	if atomic.LoadUint32(&d.started) == 1 || atomic.LoadUint32(&d.done) == 1 || atomic.LoadUint32(&d.unblock) == 1 {
+		d.misuse("AddFunc called after the execution has begun")
+		return fmt.Errorf("can't add functions after the execution has begun")
+	}
+	d.fs = append(d.fs[:len(d.fs):len(d.fs)], fs...)
+	return nil
+}
+
+// Swap replaces the functions of the Once with f and fs and returns the functions it had before.
+// The new functions are executed by the next Do() i.e. right away if Once isn't DONE yet, else after Reset().
+// The execution in progress, if any, isn't disturbed: Swap returns an error instead if the functions are running.
+// Same as while creating a Once, an error is returned if any of the functions is nil.
+func (d *Once) Swap(f FuncType, fs ...FuncType) ([]FuncType, error) {
+	fs = append([]FuncType{f}, fs...)
+	for i, f := range fs {
+		if f == nil {
+			d.misuse(fmt.Sprintf("function at index %d is nil", i))
+			return nil, fmt.Errorf("function at index %d is nil", i)
+		}
+	}
+
+	if !d.mu.TryLock() {
+		if atomic.LoadUint32(&d.running) == 1 {
+			d.misuse("Swap called while the functions are running")
+			return nil, fmt.Errorf("can't swap functions while they are running")
+		}
+		d.mu.Lock()
+	}
+	defer d.mu.Unlock()
+
+	old := d.fs
+	d.fs = fs
+	d.errFs = nil
+	return old, nil
 }
 
 // Reset resets Once for reuse.
 // It also returns if reset was actually required or not.
 // Reset is concurrency safe. In case a Do() call is already in progress(has acquired the lock), reset will happen after Do() finishes.
 func (d *Once) Reset() bool {
+	d.checker.check("Once")
+	if atomic.LoadUint32(&d.running) == 1 {
+		d.misuse("Reset called while the functions are running")
+	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	return d.reset()
+}
+
+// reset moves Once to a new generation. d.mu must be held by the caller.
+func (d *Once) reset() bool {
+	atomic.AddUint64(&d.gen, 1)
+	atomic.StoreUint32(&d.started, 0)
+	atomic.StoreUint32(&d.failed, 0)
+	d.stateMu.Lock()
+	d.winner, d.hasWinner = nil, false
+	d.err = nil
+	d.value, d.hasValue = nil, false
+	if d.doneChClosed {
+		d.doneCh, d.doneChClosed = nil, false
+	}
+	d.stateMu.Unlock()
+	atomic.StoreInt64(&d.duration, 0)
+	if d.resetTimer != nil {
+		d.resetTimer.Stop()
+		d.resetTimer = nil
+	}
 	atomic.StoreUint32(&d.unblock, 0)
 	res := atomic.LoadUint32(&d.done) == 1
 	atomic.StoreUint32(&d.done, 0)
 	return res
 }
 
+// scheduleReset schedules a reset of the current generation after resetAfter. d.mu must be held by the caller.
+// The reset is skipped if the generation has changed in the meantime i.e. Reset() was called, or if Once was closed.
+func (d *Once) scheduleReset() {
+	gen := d.gen
+	d.resetTimer = clockOrReal(d.clock).AfterFunc(d.resetAfter, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.gen == gen && atomic.LoadUint32(&d.unblock) == 0 {
+			d.reset()
+		}
+	})
+}
+
 // Close() unblocks all goroutines waiting on Done(true)
+// A closed Once doesn't execute the function/s anymore i.e. Do() is a no-op till Reset() is called.
 func (d *Once) Close() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	d.close()
+}
+
+// CloseGraceful is same as Close() but if the function/s are being executed, it first waits for the execution
+// to finish so that the waiters released by it observe the final state of the execution.
+// If ctx is done before the execution finishes, CloseGraceful gives up without closing and returns ctx.Err().
+// If nothing is being executed it behaves like Close().
+func (d *Once) CloseGraceful(ctx context.Context) error {
+	idle := d.waitFor(ctx, func() bool { return atomic.LoadUint32(&d.running) == 0 })
+	if !idle {
+		return ctx.Err()
+	}
+	d.Close()
+	return nil
+}
+
+// close moves Once to closed state and unblocks all waiting goroutines. d.mu must be held by the caller.
+func (d *Once) close() {
 	atomic.StoreUint32(&d.unblock, 1)
-	d.unblockCond.Broadcast()
+	d.signal()
 }
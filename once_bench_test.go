@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// benchmarkDoneWaiters measures the time to release n goroutines blocked on Done(true) when the Once becomes DONE.
+// If withContext is true, the goroutines use DoneContext with a cancelable context instead.
+func benchmarkDoneWaiters(b *testing.B, n int, withContext bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		o, _ := NewOnce(true, false, VerifyNone, returnTrue)
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for j := 0; j < n; j++ {
+			go func() {
+				defer wg.Done()
+				if withContext {
+					o.DoneContext(ctx)
+				} else {
+					o.Done(true)
+				}
+			}()
+		}
+		for o.Waiters() != n {
+			runtime.Gosched()
+		}
+		b.StartTimer()
+
+		o.Do()
+		wg.Wait()
+	}
+}
+
+func BenchmarkDoneWaiters1(b *testing.B)          { benchmarkDoneWaiters(b, 1, false) }
+func BenchmarkDoneWaiters100(b *testing.B)        { benchmarkDoneWaiters(b, 100, false) }
+func BenchmarkDoneWaiters1000(b *testing.B)       { benchmarkDoneWaiters(b, 1000, false) }
+func BenchmarkDoneContextWaiters100(b *testing.B) { benchmarkDoneWaiters(b, 100, true) }
+
+func BenchmarkDoFastPath(b *testing.B) {
+	o, _ := NewDefaultOnce(returnTrue)
+	o.Do()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			o.Do()
+		}
+	})
+}
+
+func BenchmarkDoneFastPath(b *testing.B) {
+	o, _ := NewDefaultOnce(returnTrue)
+	o.Do()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			o.Done(true)
+		}
+	})
+}
+
+func BenchmarkDoOrWaitFastPath(b *testing.B) {
+	o, _ := NewDefaultOnce(returnTrue)
+	o.Do()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			o.DoOrWait()
+		}
+	})
+}
+
+// BenchmarkStdOnce compares the Do() of a StdOnce which is DONE with the one of golang's sync.Once.
+func BenchmarkStdOnce(b *testing.B) {
+	f := func() {}
+	b.Run("StdOnce", func(b *testing.B) {
+		o, _ := NewStdOnce()
+		o.Do(f)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				o.Do(f)
+			}
+		})
+	})
+	b.Run("sync.Once", func(b *testing.B) {
+		var o sync.Once
+		o.Do(f)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				o.Do(f)
+			}
+		})
+	})
+}
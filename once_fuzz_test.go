@@ -0,0 +1,134 @@
+package sync
+
+// Fuzz test for the state machine of Once. The seed corpus runs as part of `go test`.
+// To fuzz with random inputs, preferably with the race detector, run:
+//
+//	go test -race -run '^$' -fuzz FuzzOnceStateMachine -fuzztime 60s
+//
+// Each input is decoded into the options of a Once and a sequence of operations for a few goroutines
+// which run concurrently. After every run below invariants are checked:
+//   - the functions are executed at most once per generation (a generation ends with Reset), unless a
+//     panic in lazy mode left the Once not DONE
+//   - Done(false) never reverts from true to false if there is no Reset in the sequence
+//   - no Done(true) blocks forever once the Once is DONE or closed
+//   - no panic escapes Do() when suppressPanic = true
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	opDo = iota
+	opTryDo
+	opDoneNoBlock
+	opDoneBlock
+	opClose
+	opReset
+	opCount
+)
+
+func FuzzOnceStateMachine(f *testing.F) {
+	f.Add([]byte{0, 0, opDo, opDoneBlock, opDo, opDoneNoBlock})
+	f.Add([]byte{1, 1, opDo, opReset, opDo, opDoneBlock, opClose, opDo})
+	f.Add([]byte{3, 2, opTryDo, opDoneBlock, opReset, opClose, opDoneBlock, opDo, opReset})
+	f.Add([]byte{7, 3, opClose, opDoneBlock, opReset, opDo, opDo, opTryDo, opDoneNoBlock})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) < 3 {
+			return
+		}
+		lazyDone := data[0]&1 == 1
+		suppressPanic := data[0]&2 == 2
+		panics := data[0]&4 == 4
+		goroutines := int(data[1]%3) + 2
+		ops := data[2:]
+		if len(ops) > 64 {
+			ops = ops[:64]
+		}
+		hasReset := false
+		for _, op := range ops {
+			hasReset = hasReset || op%opCount == opReset
+		}
+
+		var o *Once
+		var mu sync.Mutex
+		runs := map[uint64]int{}
+		// the function runs while holding o.mu, so it can read the generation
+		fn := func() bool {
+			mu.Lock()
+			runs[o.gen]++
+			mu.Unlock()
+			if panics {
+				panic("fuzz")
+			}
+			return true
+		}
+
+		var err error
+		o, err = NewOnce(lazyDone, suppressPanic, VerifyNone, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		finished := make(chan struct{})
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				seenDone := false
+				for i := g; i < len(ops); i += goroutines {
+					func() {
+						defer func() {
+							if p := recover(); p != nil && suppressPanic {
+								t.Errorf("panic escaped with suppressPanic = true: %v", p)
+							}
+						}()
+						switch ops[i] % opCount {
+						case opDo:
+							o.Do()
+						case opTryDo:
+							o.TryDo()
+						case opDoneNoBlock:
+							done := o.Done(false)
+							if seenDone && !done && !hasReset {
+								t.Errorf("done reverted without Reset")
+							}
+							seenDone = done
+						case opDoneBlock:
+							o.Done(true)
+						case opClose:
+							o.Close()
+						case opReset:
+							o.Reset()
+						}
+					}()
+				}
+			}(g)
+		}
+		go func() { wg.Wait(); close(finished) }()
+
+		// goroutines blocked in Done(true) must be released once the Once is closed.
+		// Close is repeated since a goroutine may Reset after it.
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case <-finished:
+				mu.Lock()
+				defer mu.Unlock()
+				for gen, n := range runs {
+					if n > 1 && !(lazyDone && panics) {
+						t.Errorf("functions executed %d times in generation %d", n, gen)
+					}
+				}
+				return
+			case <-time.After(time.Millisecond * 5):
+				o.Close()
+			case <-deadline:
+				t.Fatalf("Done(true) blocked even though the Once was closed")
+			}
+		}
+	})
+}
@@ -1,7 +1,10 @@
 package sync
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,7 +13,7 @@ import (
 
 func returnTrue() bool  { return true }
 func returnFalse() bool { return false }
-func doPanic() bool     { panic(1); return false }
+func doPanic() bool     { panic(1) }
 func returnTrueWithDelay(t time.Duration) func() bool {
 	return func() bool { time.Sleep(t); return true }
 }
@@ -436,8 +439,10 @@ func TestBlockingDoneMultipleGoroutine(t *testing.T) {
 	// call Done with block=true
 	var t1, t2 time.Time
 	var wg sync.WaitGroup
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
 
 	// block for done to be set
 	o.Done(true)
@@ -467,12 +472,12 @@ func TestBlockingDoneMultipleGoroutineExplicitClose(t *testing.T) {
 	var t1, t2 time.Time
 
 	var wg sync.WaitGroup
-	wg.Add(1) // first goroutine calls wg.Done() after calling Add. this avoids this test case having a data race for the waitgroup
-	go func() { wg.Add(1); wg.Done(); assert.Equal(t, false, o.Done(true)); t1 = time.Now(); wg.Done() }()
-	go func() { wg.Add(1); assert.Equal(t, false, o.Done(true)); t2 = time.Now(); wg.Done() }()
+	wg.Add(3)
+	go func() { assert.Equal(t, false, o.Done(true)); t1 = time.Now(); wg.Done() }()
+	go func() { assert.Equal(t, false, o.Done(true)); t2 = time.Now(); wg.Done() }()
 
 	// close after 5ms
-	go func() { wg.Add(1); time.Sleep(time.Millisecond * 5); o.Close(); wg.Done() }()
+	go func() { time.Sleep(time.Millisecond * 5); o.Close(); wg.Done() }()
 
 	wg.Wait()
 
@@ -498,8 +503,10 @@ func TestBlockingDoneAfterReset(t *testing.T) {
 	// call Done with block=true
 	var t1, t2 time.Time
 	var wg sync.WaitGroup
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
 
 	// block for done to be set
 	o.Done(true)
@@ -516,8 +523,10 @@ func TestBlockingDoneAfterReset(t *testing.T) {
 	go func() { assert.Equal(t, true, o.Do()) }()
 
 	// call Done with block=true
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
-	go func() { wg.Add(1); assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t1 = time.Now(); wg.Done() }()
+	wg.Add(1)
+	go func() { assert.Equal(t, true, o.Done(true)); t2 = time.Now(); wg.Done() }()
 
 	// block for done to be set
 	o.Done(true)
@@ -526,3 +535,1513 @@ func TestBlockingDoneAfterReset(t *testing.T) {
 	assert.True(t, t2.Sub(ts) > time.Millisecond*4)
 	assert.True(t, t1.Sub(ts) > time.Millisecond*4)
 }
+
+func TestNewOnceWithOptions(t *testing.T) {
+	var err error
+	_, err = NewOnceWithOptions(nil)
+	assert.NotEqual(t, err, nil)
+
+	_, err = NewOnceWithOptions([]FuncType{returnTrue}, WithVerify(VerifyAll))
+	assert.NotEqual(t, err, nil)
+
+	o, err := NewOnceWithOptions([]FuncType{returnTrue, returnFalse}, WithLazyDone(true), WithVerify(VerifyAll))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.Done(false))
+
+	o, err = NewOnceWithOptions([]FuncType{doPanic}, WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { assert.Equal(t, true, o.Do()) })
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestProgress(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	var calls [][2]int
+	progress := func(completed, total int) { calls = append(calls, [2]int{completed, total}) }
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrue, returnFalse, returnTrue}, WithProgress(progress))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, [][2]int{{1, 3}, {2, 3}, {3, 3}}, calls)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, 3, len(calls))
+
+	// functions skipped by verify are not reported
+	calls = nil
+	o, err = NewOnceWithOptions([]FuncType{returnFalse, returnTrue, returnTrue}, WithLazyDone(true), WithVerify(VerifyAll), WithProgress(progress))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, [][2]int{{1, 3}}, calls)
+}
+
+func TestProgressOnlyWinner(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	var calls int32
+	progress := func(completed, total int) { atomic.AddInt32(&calls, 1) }
+	o, err = NewOnceWithOptions([]FuncType{returnTrueWithDelay(time.Millisecond * 2), returnTrue}, WithProgress(progress))
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() { o.Do(); wg.Done() }()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDoAfterClose(t *testing.T) {
+	o, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o.Close()
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.Done(true))
+
+	// Reset re-opens the Once
+	assert.Equal(t, false, o.Reset())
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, true, o.Done(true))
+}
+
+func TestDoAndClose(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	executed := 0
+	f := func() bool { executed++; return false }
+	o, err = NewOnce(true, false, VerifyAll, f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.DoAndClose())
+	assert.Equal(t, 1, executed)
+	assert.Equal(t, false, o.Done(false))
+
+	// no further executions even though the Once isn't DONE
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.DoAndClose())
+	assert.Equal(t, 1, executed)
+
+	ts := time.Now()
+	assert.Equal(t, false, o.Done(true))
+	assert.True(t, time.Now().Sub(ts) < time.Millisecond)
+
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.DoAndClose())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, true, o.Done(true))
+
+	// closed even if the function panics
+	o, err = NewDefaultOnce(doPanic)
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { o.DoAndClose() })
+	assert.Equal(t, false, o.Do())
+	o.mu.Lock()
+	assert.Equal(t, uint32(1), o.unblock)
+	o.mu.Unlock()
+}
+
+func TestDoContext(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*10))
+	assert.Equal(t, err, nil)
+	go func() { assert.Equal(t, true, o.Do()) }()
+	time.Sleep(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	res, err := o.DoContext(ctx)
+	assert.Equal(t, false, res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// the running execution isn't affected
+	assert.Equal(t, true, o.Done(true))
+	res, err = o.DoContext(context.Background())
+	assert.Equal(t, false, res)
+	assert.Equal(t, err, nil)
+
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	res, err = o.DoContext(context.Background())
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+
+	o, err = NewDefaultOnce(doPanic)
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { o.DoContext(context.Background()) })
+}
+
+func TestDoContextTimeout(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	// the execution started by the caller which timed out completes in the background and sets DONE
+	release := make(chan struct{})
+	o, err = NewOnce(true, false, VerifyNone, func() bool {
+		<-release
+		return true
+	})
+	assert.Equal(t, err, nil)
+	res, err := o.DoWithTimeout(time.Millisecond)
+	assert.Equal(t, false, res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, StateRunning, o.State())
+	close(release)
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, StateDone, o.State())
+
+	// with non sticky failures, a timed out execution which fails can be retried
+	release = make(chan struct{})
+	calls := 0
+	o, err = NewOnceWithOptions([]FuncType{func() bool {
+		calls++
+		if calls == 1 {
+			<-release
+			panic("timed out")
+		}
+		return true
+	}}, WithStickyFailure(false))
+	assert.Equal(t, err, nil)
+	res, err = o.DoWithTimeout(time.Millisecond)
+	assert.Equal(t, false, res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	close(release)
+	for i := 0; o.Err() == nil; i++ {
+		if i == 1000 {
+			t.Fatal("panic of the abandoned execution not recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+	assert.Equal(t, StateFailed, o.State())
+	assert.Equal(t, false, o.Done(false))
+	res, err = o.DoWithTimeout(time.Second)
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+}
+
+func TestNilFunctions(t *testing.T) {
+	var err error
+	_, err = NewDefaultOnce(nil)
+	assert.EqualError(t, err, "function at index 0 is nil")
+
+	_, err = NewOnce(false, false, VerifyNone, returnTrue, returnFalse, nil)
+	assert.EqualError(t, err, "function at index 2 is nil")
+
+	_, err = NewOnceWithOptions([]FuncType{returnTrue, nil}, WithLazyDone(true))
+	assert.EqualError(t, err, "function at index 1 is nil")
+
+	var g Group
+	_, err = g.Do("key", nil)
+	assert.EqualError(t, err, "function at index 0 is nil")
+}
+
+// TestConcurrentAccessRace is meant to be run with -race. It exercises all the methods which access
+// the functions and the state of Once concurrently.
+func TestConcurrentAccessRace(t *testing.T) {
+	var counter int32
+	f := func() bool { atomic.AddInt32(&counter, 1); return true }
+	o, err := NewOnce(true, true, VerifyAll, f, returnTrue)
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				switch (i + j) % 9 {
+				case 0, 1:
+					o.Do()
+				case 2:
+					o.Reset()
+				case 3:
+					o.Done(false)
+				case 4:
+					o.DoContext(context.Background())
+				case 5:
+					if j%50 == 0 {
+						o.DoAndClose()
+					}
+				case 6:
+					o.DoAlso(returnTrue)
+				case 7:
+					// replace the functions while other goroutines execute them
+					o.Swap(f, returnTrue)
+				case 8:
+					// succeeds only right after a Reset, else returns an error
+					o.AddFunc(returnTrue)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&counter) > 0)
+}
+
+// waitForWaiters polls till the number of goroutines blocked on o is n.
+func waitForWaiters(t *testing.T, o *Once, n int) {
+	for i := 0; o.Waiters() != n; i++ {
+		if i == 1000 {
+			t.Fatalf("expected %d waiters, got %d", n, o.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaiters(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	const k = 5
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 0, o.Waiters())
+
+	var wg sync.WaitGroup
+	wg.Add(k)
+	for i := 0; i < k; i++ {
+		go func() { o.Done(true); wg.Done() }()
+	}
+	waitForWaiters(t, o, k)
+	o.Do()
+	wg.Wait()
+	assert.Equal(t, 0, o.Waiters())
+
+	// waiters released by Close
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	wg.Add(k)
+	for i := 0; i < k; i++ {
+		go func() { o.Done(true); wg.Done() }()
+	}
+	waitForWaiters(t, o, k)
+	o.Close()
+	wg.Wait()
+	assert.Equal(t, 0, o.Waiters())
+
+	// waiters giving up on context
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(1)
+	go func() { WaitAllContext(ctx, o); wg.Done() }()
+	waitForWaiters(t, o, 1)
+	cancel()
+	wg.Wait()
+	assert.Equal(t, 0, o.Waiters())
+}
+
+func TestTryDo(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*4))
+	assert.Equal(t, err, nil)
+	go func() { assert.Equal(t, true, o.Do()) }()
+	time.Sleep(time.Millisecond)
+
+	ts := time.Now()
+	assert.Equal(t, false, o.TryDo())
+	assert.True(t, time.Now().Sub(ts) < time.Millisecond)
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, false, o.TryDo())
+
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.TryDo())
+	assert.Equal(t, false, o.TryDo())
+	assert.Equal(t, false, o.Do())
+}
+
+func TestDoSlowWinner(t *testing.T) {
+	const losers = 4
+	var (
+		err     error
+		o       *Once
+		written int // written by the winner without synchronization, the race detector checks the losers' reads
+		wg      sync.WaitGroup
+	)
+
+	started := make(chan struct{})
+	o, err = NewOnce(true, false, VerifyNone, func() bool {
+		close(started)
+		time.Sleep(time.Millisecond * 5)
+		written = 1
+		return true
+	})
+	assert.Equal(t, err, nil)
+	go func() { assert.Equal(t, true, o.Do()) }()
+	<-started
+
+	wg.Add(losers)
+	for i := 0; i < losers; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, false, o.Do())
+			assert.Equal(t, 1, written)
+			assert.Equal(t, uint64(1), o.Count())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDoOrWait(t *testing.T) {
+	var (
+		err     error
+		o       *Once
+		written int
+	)
+
+	// DONE is set before the function runs, so Do() would return right away for a loser
+	started := make(chan struct{})
+	o, err = NewDefaultOnce(func() bool {
+		close(started)
+		time.Sleep(time.Millisecond * 5)
+		written = 1
+		return true
+	})
+	assert.Equal(t, err, nil)
+	go func() { assert.Equal(t, true, o.DoOrWait()) }()
+	<-started
+
+	assert.Equal(t, false, o.DoOrWait())
+	assert.Equal(t, 1, written)
+	assert.Equal(t, uint64(1), o.Count())
+	assert.Equal(t, false, o.DoOrWait())
+
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.DoOrWait())
+	assert.Equal(t, false, o.DoOrWait())
+}
+
+func TestDoE(t *testing.T) {
+	o, err := NewOnce(false, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+	res, err := o.DoE()
+	assert.Equal(t, true, res)
+	assert.True(t, errors.Is(err, ErrPanicked))
+	res, err = o.DoE()
+	assert.Equal(t, false, res)
+	assert.True(t, errors.Is(err, ErrPanicked))
+
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	res, err = o.DoE()
+	assert.Equal(t, true, res)
+	assert.Equal(t, err, nil)
+}
+
+func TestStoreValue(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, func() bool {
+		time.Sleep(time.Millisecond * 2)
+		o.StoreValue("config")
+		return true
+	})
+	assert.Equal(t, err, nil)
+	v, ok := o.LoadValue()
+	assert.Equal(t, nil, v)
+	assert.Equal(t, false, ok)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, true, o.Done(true))
+		v, ok := o.LoadValue()
+		assert.Equal(t, "config", v)
+		assert.Equal(t, true, ok)
+	}()
+	waitForWaiters(t, o, 1)
+	assert.Equal(t, true, o.Do())
+	<-done
+
+	o.Reset()
+	v, ok = o.LoadValue()
+	assert.Equal(t, nil, v)
+	assert.Equal(t, false, ok)
+}
+
+func TestDoAlso(t *testing.T) {
+	const n = 8
+	var (
+		err    error
+		o      *Once
+		mu     sync.Mutex
+		calls  []string
+		extras int
+		wg     sync.WaitGroup
+	)
+
+	record := func(name string) FuncType {
+		return func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return true
+		}
+	}
+	o, err = NewOnce(true, false, VerifyAll, record("f"))
+	assert.Equal(t, err, nil)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if o.DoAlso(record("extra")) {
+				mu.Lock()
+				extras++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, extras)
+	assert.Equal(t, []string{"f", "extra"}, calls)
+	assert.Equal(t, false, o.DoAlso(record("late")))
+	assert.Equal(t, false, o.Do())
+
+	// extras are not kept across Reset
+	o.Reset()
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, []string{"f", "extra", "f"}, calls)
+
+	// extras take part in verify
+	o, err = NewOnce(true, false, VerifyAll, returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.DoAlso(returnFalse))
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.DoAlso())
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestReadinessProbe(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	release := make(chan struct{})
+	o, err = NewOnce(true, true, VerifyAll, func() bool {
+		<-release
+		return true
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ErrNotReady, o.ReadinessProbe())
+	go o.Do()
+	o.WaitReady()
+	assert.Equal(t, ErrNotReady, o.ReadinessProbe())
+	close(release)
+	o.Done(true)
+	assert.Equal(t, nil, o.ReadinessProbe())
+
+	o, err = NewOnce(true, true, VerifyAll, doPanic)
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.True(t, errors.Is(o.ReadinessProbe(), ErrPanicked))
+
+	o, err = NewOnce(true, true, VerifyAll, returnFalse)
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, ErrNotReady, o.ReadinessProbe())
+	o.Close()
+	assert.Equal(t, ErrClosed, o.ReadinessProbe())
+}
+
+func TestSubscribe(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	isClosed := func(ch <-chan struct{}) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// subscribe then complete
+	o, err = NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+	ch1, cancel1 := o.Subscribe()
+	ch2, _ := o.Subscribe()
+	assert.Equal(t, false, isClosed(ch1))
+	assert.Equal(t, true, o.Do())
+	<-ch1
+	<-ch2
+	cancel1()
+
+	// subscribe after done
+	ch, cancel := o.Subscribe()
+	assert.Equal(t, true, isClosed(ch))
+	cancel()
+
+	// subscribe then cancel
+	o, err = NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+	ch, cancel = o.Subscribe()
+	cancel()
+	cancel()
+	o.stateMu.Lock()
+	assert.Equal(t, 0, len(o.subs))
+	o.stateMu.Unlock()
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, false, isClosed(ch))
+
+	// close completes the subscriptions too
+	o, err = NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+	ch, _ = o.Subscribe()
+	o.Close()
+	<-ch
+}
+
+func TestStickyFailure(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	// retryable: a panic doesn't leave DONE set even though it's set before executing
+	calls := 0
+	o, err = NewOnceWithOptions([]FuncType{func() bool {
+		calls++
+		if calls == 1 {
+			panic("first")
+		}
+		return true
+	}}, WithStickyFailure(false))
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { o.Do() })
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, 2, calls)
+
+	// retryable: errors recorded in Err count as failure too
+	o, err = NewOnceErr([]ErrFuncType{func() error { return errors.New("failed") }},
+		WithVerify(VerifyNone), WithStickyFailure(false))
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, false, o.Done(false))
+
+	// sticky: a failed verification sets DONE
+	o, err = NewOnceWithOptions([]FuncType{returnFalse},
+		WithLazyDone(true), WithVerify(VerifyAll), WithStickyFailure(true))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, false, o.Do())
+
+	// sticky: a suppressed panic sets DONE
+	o, err = NewOnceWithOptions([]FuncType{doPanic},
+		WithLazyDone(true), WithSuppressPanic(true), WithStickyFailure(true))
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, true, o.Done(false))
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+}
+
+func TestDoneContext(t *testing.T) {
+	o, err := NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, false, o.DoneContext(ctx))
+	assert.Equal(t, 0, o.Waiters())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, true, o.DoneContext(context.Background()))
+	}()
+	waitForWaiters(t, o, 1)
+	assert.Equal(t, true, o.Do())
+	<-done
+
+	// returns the current state right away if ctx is already done
+	assert.Equal(t, true, o.DoneContext(ctx))
+}
+
+func TestDoneChan(t *testing.T) {
+	o, err := NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+
+	ch := o.DoneChan()
+	assert.True(t, ch == o.DoneChan())
+	select {
+	case <-ch:
+		t.Fatal("closed before DONE")
+	case <-time.After(time.Millisecond):
+	}
+	go o.Do()
+	<-ch
+	assert.Equal(t, true, o.Done(false))
+	<-o.DoneChan()
+
+	// a new channel after Reset
+	o.Reset()
+	ch = o.DoneChan()
+	select {
+	case <-ch:
+		t.Fatal("closed after Reset")
+	default:
+	}
+	o.Close()
+	<-ch
+	assert.Equal(t, false, o.Done(false))
+}
+
+func TestInterfaces(t *testing.T) {
+	o, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+
+	trigger := func(d Doer) bool { return d.Do() }
+	ready := func(w Waiter) bool { return w.Done(true) }
+	assert.Equal(t, true, trigger(o))
+	assert.Equal(t, true, ready(o))
+}
+
+func TestWaiter(t *testing.T) {
+	o, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	c, err := NewOnceCompact(returnTrue)
+	assert.Equal(t, err, nil)
+	var z OnceZ
+
+	for _, w := range []Waiter{o, c, &z} {
+		ch := w.DoneChan()
+		select {
+		case <-ch:
+			t.Fatalf("%T: DoneChan closed before Do", w)
+		default:
+		}
+		waited := make(chan struct{})
+		go func() {
+			w.Wait()
+			close(waited)
+		}()
+		switch d := w.(type) {
+		case Doer:
+			d.Do()
+		case *OnceZ:
+			d.Do(returnTrue)
+		}
+		<-ch
+		<-waited
+		<-w.DoneChan()
+		assert.Equal(t, true, w.Done(false))
+	}
+
+	// a new channel after Reset, closed by Close
+	z.Reset()
+	ch := z.DoneChan()
+	select {
+	case <-ch:
+		t.Fatal("DoneChan closed after Reset")
+	default:
+	}
+	z.Close()
+	<-ch
+	z.Wait()
+	c.Close()
+	<-c.DoneChan()
+}
+
+func TestDuration(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*4))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, time.Duration(0), o.Duration())
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, time.Duration(0), o.Duration())
+	o.Done(true)
+	assert.True(t, o.Duration() >= time.Millisecond*4)
+
+	// recorded even on panic
+	o, err = NewOnce(false, true, VerifyNone, returnTrueWithDelay(time.Millisecond*2), doPanic)
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.True(t, o.Duration() >= time.Millisecond*2)
+}
+
+func TestDoIf(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	executed := 0
+	f := func() bool { executed++; return true }
+	yes := func() bool { return true }
+	no := func() bool { return false }
+
+	o, err = NewDefaultOnce(f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.DoIf(no))
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, 0, executed)
+
+	assert.Equal(t, true, o.DoIf(yes))
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, 1, executed)
+
+	// pred isn't evaluated once DONE
+	assert.Equal(t, false, o.DoIf(func() bool { t.Fatal("pred called"); return true }))
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, 1, executed)
+}
+
+func TestClone(t *testing.T) {
+	var executed int32
+	f := func() bool { atomic.AddInt32(&executed, 1); return true }
+	o, err := NewOnce(true, true, VerifyAll, f, doPanic)
+	assert.Equal(t, err, nil)
+
+	c := o.Clone()
+	assert.Equal(t, true, c.lazyDone)
+	assert.Equal(t, true, c.suppressPanic)
+	assert.Equal(t, VerifyAll, c.verify)
+
+	// running the clone doesn't affect the original
+	assert.NotPanics(t, func() { c.Do() })
+	assert.Equal(t, int32(1), executed)
+	c.Close()
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, uint32(0), atomic.LoadUint32(&o.unblock))
+
+	o, err = NewDefaultOnce(f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	c = o.Clone()
+	assert.Equal(t, false, c.Done(false))
+	assert.Equal(t, true, c.Do())
+	assert.Equal(t, int32(3), executed)
+}
+
+func TestStrict(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	assert.PanicsWithValue(t, "sync: function at index 1 is nil", func() {
+		NewOnceWithOptions([]FuncType{returnTrue, nil}, WithStrict())
+	})
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrue}, WithStrict())
+	assert.Equal(t, err, nil)
+	o.Close()
+	assert.PanicsWithValue(t, "sync: Do called on a closed Once", func() { o.Do() })
+	assert.Panics(t, func() { o.TryDo() })
+	assert.Panics(t, func() { o.DoAndClose() })
+
+	// panics even if suppressPanic = true
+	o, err = NewOnceWithOptions([]FuncType{returnTrue}, WithStrict(), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	o.Close()
+	assert.Panics(t, func() { o.DoAndClose() })
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrueWithDelay(time.Millisecond * 4)}, WithStrict())
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	assert.PanicsWithValue(t, "sync: Reset called while the functions are running", func() { o.Reset() })
+
+	// non-strict mode doesn't panic
+	o, err = NewDefaultOnce(returnTrueWithDelay(time.Millisecond * 4))
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	assert.NotPanics(t, func() { o.Reset() })
+	o.Close()
+	assert.NotPanics(t, func() { o.Do() })
+}
+
+func TestDoneNotify(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyAll, returnTrue)
+	assert.Equal(t, err, nil)
+	ch1 := make(chan struct{}, 1)
+	ch2 := make(chan struct{}, 1)
+	o.DoneNotify(ch1)
+	o.DoneNotify(ch2)
+	assert.Equal(t, 0, len(ch1))
+
+	o.Do()
+	<-ch1
+	<-ch2
+
+	// already done: notified immediately
+	ch3 := make(chan struct{}, 1)
+	o.DoneNotify(ch3)
+	<-ch3
+
+	// notified again after Reset and the next execution
+	o.Reset()
+	o.Do()
+	<-ch1
+	<-ch2
+	<-ch3
+
+	// not notified if the execution doesn't set DONE, but notified on Close
+	o, err = NewOnce(true, false, VerifyAll, returnFalse)
+	assert.Equal(t, err, nil)
+	o.DoneNotify(ch1)
+	o.Do()
+	assert.Equal(t, 0, len(ch1))
+	o.Close()
+	<-ch1
+
+	// unbuffered channel without a receiver doesn't block
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o.DoneNotify(make(chan struct{}))
+	assert.Equal(t, true, o.Do())
+}
+
+// TestDoDoneRace is meant to be run with -race. It hammers Do() and Done() from many goroutines.
+func TestDoDoneRace(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		o, err := NewOnce(true, false, VerifyNone, returnTrue)
+		assert.Equal(t, err, nil)
+
+		var winners int32
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if o.Do() {
+					atomic.AddInt32(&winners, 1)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 10; k++ {
+					o.Done(false)
+				}
+				o.Done(true)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), winners)
+	}
+}
+
+func TestResetAfter(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	var executed int32
+	f := func() bool { atomic.AddInt32(&executed, 1); return true }
+	c := NewManualClock(clockEpoch)
+	o, err = NewOnceWithOptions([]FuncType{f}, WithResetAfter(time.Millisecond*10), WithClock(c))
+	assert.Equal(t, err, nil)
+
+	// first window
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&executed))
+	c.Advance(time.Millisecond * 9)
+	assert.Equal(t, true, o.Done(false))
+
+	// second window
+	c.Advance(time.Millisecond)
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executed))
+
+	// an explicit Reset cancels the pending reset of the earlier generation
+	c.Advance(time.Millisecond * 5)
+	o.Reset()
+	assert.Equal(t, true, o.Do())
+	c.Advance(time.Millisecond * 7)
+	assert.Equal(t, true, o.Done(false))
+	c.Advance(time.Millisecond * 3)
+	assert.Equal(t, false, o.Done(false))
+
+	// no automatic reset of a closed Once
+	o, err = NewOnceWithOptions([]FuncType{f}, WithResetAfter(time.Millisecond*5), WithClock(c))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	o.Close()
+	c.Advance(time.Hour)
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, false, o.Do())
+}
+
+func TestNewOnceTTL(t *testing.T) {
+	_, err := NewOnceTTL(0, returnTrue)
+	assert.NotEqual(t, err, nil)
+	_, err = NewOnceTTL(time.Second, nil)
+	assert.NotEqual(t, err, nil)
+
+	_, err = NewOnceTTLWithOptions(-time.Second, []FuncType{returnTrue})
+	assert.NotEqual(t, err, nil)
+
+	var executed int32
+	c := NewManualClock(clockEpoch)
+	o, err := NewOnceTTLWithOptions(time.Millisecond*10,
+		[]FuncType{func() bool { atomic.AddInt32(&executed, 1); return true }}, WithClock(c))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, StateNotStarted, o.State())
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, StateDone, o.State())
+
+	c.Advance(time.Millisecond * 10)
+	assert.Equal(t, StateNotStarted, o.State())
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executed))
+}
+
+func TestParallel(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	// functions run concurrently
+	ts := time.Now()
+	o, err = NewOnceWithOptions([]FuncType{
+		returnTrueWithDelay(time.Millisecond * 5),
+		returnTrueWithDelay(time.Millisecond * 5),
+		returnTrueWithDelay(time.Millisecond * 5),
+	}, WithParallel())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	assert.True(t, time.Now().Sub(ts) < time.Millisecond*12)
+	assert.Equal(t, true, o.Done(false))
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrue, returnFalse}, WithParallel(), WithLazyDone(true), WithVerify(VerifyAll))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, false, o.Done(false))
+
+	o, err = NewOnceWithOptions([]FuncType{returnFalse, returnTrue}, WithParallel(), WithLazyDone(true), WithVerify(VerifyFirstExit))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestParallelPanics(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	var executed int32
+	f := func() bool { atomic.AddInt32(&executed, 1); return true }
+	panicWith := func(v string) FuncType { return func() bool { panic(v) } }
+	fs := []FuncType{f, panicWith("first"), f, panicWith("second"), f}
+
+	// suppressed: all panics are captured
+	o, err = NewOnceWithOptions(fs, WithParallel(), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { assert.Equal(t, true, o.Do()) })
+	assert.Equal(t, int32(3), atomic.LoadInt32(&executed))
+	assert.NotEqual(t, o.Err(), nil)
+	assert.Contains(t, o.Err().Error(), "panic: first")
+	assert.Contains(t, o.Err().Error(), "panic: second")
+
+	// not suppressed: combined panic is raised after all functions finish
+	atomic.StoreInt32(&executed, 0)
+	o, err = NewOnceWithOptions(fs, WithParallel())
+	assert.Equal(t, err, nil)
+	defer func() {
+		p := recover()
+		assert.Equal(t, int32(3), atomic.LoadInt32(&executed))
+		assert.Contains(t, p.(error).Error(), "panic: first")
+		assert.Contains(t, p.(error).Error(), "panic: second")
+	}()
+	o.Do()
+}
+
+func TestErrSequential(t *testing.T) {
+	o, err := NewOnce(true, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, o.Err(), nil)
+	o.Do()
+	assert.EqualError(t, o.Err(), "panic: 1")
+
+	// the next execution replaces the error
+	fail := true
+	o, err = NewOnce(true, true, VerifyAll, func() bool {
+		if fail {
+			panic(1)
+		}
+		return true
+	})
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.NotEqual(t, o.Err(), nil)
+	fail = false
+	o.Do()
+	assert.Equal(t, o.Err(), nil)
+}
+
+func TestWaitReady(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*10))
+	assert.Equal(t, err, nil)
+
+	ts := time.Now()
+	go func() { time.Sleep(time.Millisecond * 2); o.Do() }()
+	o.WaitReady()
+	assert.True(t, time.Now().Sub(ts) >= time.Millisecond*2)
+	assert.True(t, time.Now().Sub(ts) < time.Millisecond*10)
+	assert.Equal(t, false, o.Done(false))
+
+	// returns immediately after completion and on a closed Once
+	o.Done(true)
+	o.WaitReady()
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o.Close()
+	o.WaitReady()
+}
+
+func TestSwap(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	calls := ""
+	f := func(name string) FuncType { return func() bool { calls += name; return true } }
+	o, err = NewDefaultOnce(f("a"))
+	assert.Equal(t, err, nil)
+
+	// swap before the first Do
+	old, err := o.Swap(f("b"), f("c"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 1, len(old))
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, "bc", calls)
+
+	// swap after DONE takes effect after Reset
+	_, err = o.Swap(f("d"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, "bc", calls)
+	o.Reset()
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, "bcd", calls)
+
+	_, err = o.Swap(f("e"), nil)
+	assert.EqualError(t, err, "function at index 1 is nil")
+
+	// rejected while running
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*4))
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	_, err = o.Swap(returnTrue)
+	assert.NotEqual(t, err, nil)
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrueWithDelay(time.Millisecond * 4)}, WithStrict())
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	assert.PanicsWithValue(t, "sync: Swap called while the functions are running", func() { o.Swap(returnTrue) })
+}
+
+func TestDoWrapper(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	var events []string
+	wrapper := func(next func()) {
+		events = append(events, "start")
+		defer func() { events = append(events, "end") }()
+		next()
+	}
+	f := func() bool { events = append(events, "f"); return true }
+
+	o, err = NewOnceWithOptions([]FuncType{f, f}, WithDoWrapper(wrapper))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, []string{"start", "f", "f", "end"}, events)
+
+	// panics propagate through the wrapper
+	events = nil
+	o, err = NewOnceWithOptions([]FuncType{f, doPanic}, WithDoWrapper(wrapper))
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { o.Do() })
+	assert.Equal(t, []string{"start", "f", "end"}, events)
+
+	// and can still be suppressed
+	events = nil
+	o, err = NewOnceWithOptions([]FuncType{f, doPanic}, WithDoWrapper(wrapper), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { assert.Equal(t, true, o.Do()) })
+	assert.Equal(t, []string{"start", "f", "end"}, events)
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+}
+
+func TestAddFunc(t *testing.T) {
+	var (
+		err   error
+		o     *Once
+		calls []int
+	)
+
+	record := func(i int) FuncType {
+		return func() bool { calls = append(calls, i); return true }
+	}
+	o, err = NewOnce(true, false, VerifyNone, record(1))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, nil, o.AddFunc(record(2), record(3)))
+	assert.NotEqual(t, nil, o.AddFunc(record(4), nil))
+	assert.Equal(t, nil, o.AddFunc())
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, []int{1, 2, 3}, calls)
+	assert.NotEqual(t, nil, o.AddFunc(record(4)))
+
+	// allowed again after Reset
+	o.Reset()
+	assert.Equal(t, nil, o.AddFunc(record(4)))
+	calls = nil
+	o.Do()
+	assert.Equal(t, []int{1, 2, 3, 4}, calls)
+
+	// an execution which didn't set DONE has still begun
+	o, err = NewOnce(true, false, VerifyAll, returnFalse)
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.NotEqual(t, nil, o.AddFunc(returnTrue))
+
+	release := make(chan struct{})
+	o, err = NewOnce(true, false, VerifyNone, func() bool { <-release; return true })
+	assert.Equal(t, err, nil)
+	go o.Do()
+	o.WaitReady()
+	assert.NotEqual(t, nil, o.AddFunc(returnTrue))
+	close(release)
+
+	o, err = NewOnceWithOptions([]FuncType{returnTrue}, WithStrict())
+	assert.Equal(t, err, nil)
+	o.Close()
+	assert.Panics(t, func() { o.AddFunc(returnTrue) })
+}
+
+func TestOnDone(t *testing.T) {
+	var (
+		err   error
+		o     *Once
+		calls []string
+	)
+
+	o, err = NewOnce(true, false, VerifyAll, returnTrue)
+	assert.Equal(t, err, nil)
+	o.OnDone(func() { calls = append(calls, "a") })
+	o.OnDone(func() { calls = append(calls, "b") })
+	assert.Equal(t, []string(nil), calls)
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, []string{"a", "b"}, calls)
+
+	// called right away when already DONE, and only once
+	o.OnDone(func() { calls = append(calls, "c") })
+	assert.Equal(t, []string{"a", "b", "c"}, calls)
+	o.Reset()
+	o.Do()
+	assert.Equal(t, []string{"a", "b", "c"}, calls)
+
+	// not called for a failed execution or Close, but kept for later
+	ok := false
+	o, err = NewOnce(true, false, VerifyAll, func() bool { return ok })
+	assert.Equal(t, err, nil)
+	o.OnDone(func() { calls = append(calls, "d") })
+	o.Do()
+	o.Close()
+	assert.Equal(t, []string{"a", "b", "c"}, calls)
+	o.Reset()
+	ok = true
+	o.Do()
+	assert.Equal(t, []string{"a", "b", "c", "d"}, calls)
+}
+
+func TestWaitErrAndClosed(t *testing.T) {
+	o, err := NewOnce(true, false, VerifyNone, returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Closed())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, ErrClosed, o.WaitErr())
+	}()
+	waitForWaiters(t, o, 1)
+	o.Close()
+	<-done
+	assert.Equal(t, true, o.Closed())
+	assert.Equal(t, ErrClosed, o.WaitErr())
+
+	o.Reset()
+	assert.Equal(t, false, o.Closed())
+	o.Do()
+	assert.Equal(t, nil, o.WaitErr())
+
+	// DONE takes precedence over closed
+	o.Close()
+	assert.Equal(t, true, o.Closed())
+	assert.Equal(t, nil, o.WaitErr())
+}
+
+func TestDoAsWinner(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond))
+	assert.Equal(t, err, nil)
+	_, ok := o.Winner()
+	assert.Equal(t, false, ok)
+
+	var wg sync.WaitGroup
+	results := make([]bool, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) { results[i] = o.DoAs(i); wg.Done() }(i)
+	}
+	wg.Wait()
+
+	token, ok := o.Winner()
+	assert.Equal(t, true, ok)
+	for i, res := range results {
+		assert.Equal(t, i == token.(int), res)
+	}
+
+	// no winner after Reset, and a winner using Do has a nil token
+	o.Reset()
+	_, ok = o.Winner()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, true, o.Do())
+	token, ok = o.Winner()
+	assert.Equal(t, nil, token)
+	assert.Equal(t, true, ok)
+
+	// no winner if the execution returned false
+	o, err = NewOnce(true, false, VerifyAll, returnFalse)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.DoAs("me"))
+	_, ok = o.Winner()
+	assert.Equal(t, false, ok)
+}
+
+func TestCloseGraceful(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	// idle
+	o, err = NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, o.CloseGraceful(context.Background()), nil)
+	assert.Equal(t, false, o.Do())
+
+	// in-flight execution completes before waiters are released
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*5))
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	released := make(chan bool)
+	go func() { released <- o.Done(true) }()
+	assert.Equal(t, o.CloseGraceful(context.Background()), nil)
+	assert.Equal(t, true, <-released)
+	assert.Equal(t, true, o.Done(false))
+
+	// gives up when ctx is done
+	o, err = NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*10))
+	assert.Equal(t, err, nil)
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, o.CloseGraceful(ctx))
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, uint32(0), atomic.LoadUint32(&o.unblock))
+}
+
+func TestCountAndGeneration(t *testing.T) {
+	var executed uint64
+	o, err := NewDefaultOnce(func() bool { executed++; return true })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, uint64(0), o.Count())
+	assert.Equal(t, uint64(0), o.Generation())
+
+	for i := uint64(1); i <= 10000; i++ {
+		assert.Equal(t, true, o.Do())
+		assert.Equal(t, false, o.Do())
+		assert.Equal(t, i, o.Count())
+		assert.Equal(t, i, executed)
+		o.Reset()
+		assert.Equal(t, i, o.Generation())
+	}
+
+	// failed executions are counted too
+	o, err = NewOnce(true, false, VerifyAll, returnFalse)
+	assert.Equal(t, err, nil)
+	o.Do()
+	o.Do()
+	assert.Equal(t, uint64(2), o.Count())
+	assert.Equal(t, uint64(0), o.Generation())
+}
+
+func TestResetClearsErr(t *testing.T) {
+	fail := true
+	o, err := NewOnce(false, true, VerifyNone, func() bool {
+		time.Sleep(time.Millisecond)
+		if fail {
+			panic("failed")
+		}
+		return true
+	})
+	assert.Equal(t, err, nil)
+
+	o.Do()
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+	assert.True(t, o.Duration() > 0)
+
+	o.Reset()
+	assert.Equal(t, o.Err(), nil)
+	assert.Equal(t, time.Duration(0), o.Duration())
+
+	fail = false
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, o.Err(), nil)
+	assert.True(t, o.Duration() > 0)
+}
+
+func TestRetry(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	calls := 0
+	failTwice := func() bool { calls++; return calls > 2 }
+	var delays []int
+	backoff := func(attempt int) time.Duration { delays = append(delays, attempt); return time.Millisecond }
+
+	o, err = NewOnceWithOptions([]FuncType{failTwice}, WithLazyDone(true), WithVerify(VerifyAll), WithRetry(5, backoff))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, o.Do())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, delays)
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, uint64(1), o.Count())
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, 3, calls)
+
+	// all attempts fail
+	calls = 0
+	o, err = NewOnceWithOptions([]FuncType{failTwice}, WithLazyDone(true), WithVerify(VerifyAll), WithRetry(2, nil))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, o.Do())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, false, o.Done(false))
+}
+
+func TestRetryOnPanic(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	calls := 0
+	panicTwice := func() bool {
+		calls++
+		if calls <= 2 {
+			panic(calls)
+		}
+		return true
+	}
+
+	o, err = NewOnceWithOptions([]FuncType{panicTwice}, WithLazyDone(true), WithRetry(3, nil), WithRetryOnPanic())
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { assert.Equal(t, true, o.Do()) })
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, o.Err(), nil)
+
+	// the last attempt's panic isn't recovered
+	calls = 0
+	o, err = NewOnceWithOptions([]FuncType{panicTwice}, WithLazyDone(true), WithRetry(2, nil), WithRetryOnPanic())
+	assert.Equal(t, err, nil)
+	assert.PanicsWithValue(t, 2, func() { o.Do() })
+	assert.Equal(t, false, o.Done(false))
+
+	// without WithRetryOnPanic panics are not retried
+	calls = 0
+	o, err = NewOnceWithOptions([]FuncType{panicTwice}, WithLazyDone(true), WithRetry(3, nil), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, 1, calls)
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+}
+
+func TestRetryBlocksLosers(t *testing.T) {
+	calls := 0
+	o, err := NewOnceWithOptions([]FuncType{func() bool { calls++; return calls > 1 }},
+		WithLazyDone(true), WithVerify(VerifyAll), WithRetry(2, func(int) time.Duration { return time.Millisecond * 5 }))
+	assert.Equal(t, err, nil)
+
+	go o.Do()
+	time.Sleep(time.Millisecond)
+	ts := time.Now()
+	assert.Equal(t, false, o.Do())
+	assert.True(t, time.Now().Sub(ts) >= time.Millisecond*3)
+	assert.Equal(t, true, o.Done(false))
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"io"
+)
+
+// OnceCloser wraps an io.Closer so that its Close() is executed only once, however many times and from however many
+// goroutines OnceCloser.Close is called. All the calls return the error of that execution. It's enforced by a Once,
+// so other goroutines can wait for the closure with Done. Clients should use NewOnceCloser to create objects.
+// An OnceCloser must not be copied after first use.
+type OnceCloser struct {
+	noCopy noCopy
+	c      io.Closer
+	o      *Once
+	err    error // error of c.Close(), read once o is DONE
+}
+
+// NewOnceCloser returns an OnceCloser wrapping c.
+func NewOnceCloser(c io.Closer) *OnceCloser {
+	// lazyDone = true, so the OnceCloser becomes DONE only after the error of Close is stored
+	o, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	return &OnceCloser{c: c, o: o}
+}
+
+// Close closes the wrapped io.Closer if it's the first call, and returns the error of that Close().
+// Concurrent calls block till it returns.
+func (c *OnceCloser) Close() error {
+	c.o.DoAlso(func() bool {
+		c.err = c.c.Close()
+		return true
+	})
+	c.o.Done(true)
+	return c.err
+}
+
+// Done returns whether the wrapped io.Closer has been closed. If block = true, it blocks till it has.
+func (c *OnceCloser) Done(block bool) bool {
+	return c.o.Done(block)
+}
+
+// Wait blocks till the wrapped io.Closer has been closed, same as Done(true).
+func (c *OnceCloser) Wait() {
+	c.o.Done(true)
+}
+
+// DoneChan returns a channel which is closed once the wrapped io.Closer has been closed, to select on it.
+func (c *OnceCloser) DoneChan() <-chan struct{} {
+	return c.o.DoneChan()
+}
+
+// WaitClosed blocks till the wrapped io.Closer has been closed and returns the error of its Close(). It returns
+// ctx.Err() if ctx is done first.
+func (c *OnceCloser) WaitClosed(ctx context.Context) error {
+	if !c.o.DoneContext(ctx) {
+		return ctx.Err()
+	}
+	return c.err
+}
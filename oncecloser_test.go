@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnceCloser(t *testing.T) {
+	errClose := errors.New("close failed")
+	c := &countingCloser{err: errClose}
+	var oc io.Closer = NewOnceCloser(c)
+
+	assert.Equal(t, errClose, oc.Close())
+	assert.Equal(t, errClose, oc.Close())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.closes))
+	assert.Equal(t, true, oc.(*OnceCloser).Done(false))
+}
+
+func TestOnceCloserConcurrent(t *testing.T) {
+	c := &countingCloser{}
+	oc := NewOnceCloser(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, oc.WaitClosed(ctx))
+
+	done := make(chan bool)
+	go func() { done <- oc.Done(true) }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, nil, oc.Close())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, true, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.closes))
+	assert.Equal(t, nil, oc.WaitClosed(context.Background()))
+}
@@ -0,0 +1,58 @@
+package sync
+
+import "errors"
+
+// ErrFuncType is the type of functions which report failure with an error instead of a bool.
+type ErrFuncType func() error
+
+// NewOnceErr returns a Once which executes error returning functions. A function returning a non-nil error fails
+// the execution: the functions after it are not executed, the Once doesn't become DONE, and the error is what
+// Err() and DoE() report for the execution. So DoE() returns false with the first error encountered, and Done() tells
+// whether the execution succeeded. A later Do() executes the functions again, same as with verify = VerifyAll.
+//
+// The defaults are lazyDone = true and verify = VerifyAll, opts are applied after them. With other verify options
+// the functions map to FuncType as returning err == nil, and Err() still reports the first error.
+// If suppressPanic = true, a panic is recorded by Err() same as with NewOnce.
+// With WithParallel all the functions are executed, and Err() reports all their errors and panics combined.
+func NewOnceErr(fs []ErrFuncType, opts ...Option) (*Once, error) {
+	var d *Once
+	wrapped := errFuncs(fs, func(err error) { d.addErr(err) })
+	d, err := NewOnceWithOptions(wrapped, append([]Option{WithLazyDone(true), WithVerify(VerifyAll)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	d.errFs = append([]ErrFuncType{}, fs...)
+	return d, nil
+}
+
+// errFuncs wraps fs as FuncType, passing the errors they return to addErr. A nil function stays nil, for
+// NewOnceWithOptions to report.
+func errFuncs(fs []ErrFuncType, addErr func(error)) []FuncType {
+	wrapped := make([]FuncType, len(fs))
+	for i, f := range fs {
+		if f == nil {
+			continue
+		}
+		f := f
+		wrapped[i] = func() bool {
+			if err := f(); err != nil {
+				addErr(err)
+				return false
+			}
+			return true
+		}
+	}
+	return wrapped
+}
+
+// addErr records err for the latest execution. In parallel mode all the errors are combined with errors.Join,
+// otherwise only the first one is kept.
+func (d *Once) addErr(err error) {
+	d.stateMu.Lock()
+	if d.err == nil {
+		d.err = err
+	} else if d.parallel {
+		d.err = errors.Join(d.err, err)
+	}
+	d.stateMu.Unlock()
+}
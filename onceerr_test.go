@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOnceErr(t *testing.T) {
+	var (
+		err   error
+		o     *Once
+		calls []int
+	)
+
+	_, err = NewOnceErr(nil)
+	assert.NotEqual(t, err, nil)
+	_, err = NewOnceErr([]ErrFuncType{nil})
+	assert.NotEqual(t, err, nil)
+
+	errFirst := errors.New("first")
+	fail := true
+	o, err = NewOnceErr([]ErrFuncType{
+		func() error {
+			calls = append(calls, 1)
+			return nil
+		},
+		func() error {
+			calls = append(calls, 2)
+			if fail {
+				return errFirst
+			}
+			return nil
+		},
+		func() error {
+			calls = append(calls, 3)
+			return errors.New("never")
+		},
+	})
+	assert.Equal(t, err, nil)
+
+	res, err := o.DoE()
+	assert.Equal(t, false, res)
+	assert.Equal(t, errFirst, err)
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, []int{1, 2}, calls)
+
+	// the failed execution is retried by the next call
+	fail = false
+	calls = nil
+	res, err = o.DoE()
+	assert.Equal(t, false, res)
+	assert.Equal(t, "never", err.Error())
+	assert.Equal(t, []int{1, 2, 3}, calls)
+	assert.Equal(t, false, o.Done(false))
+}
+
+func TestNewOnceErrSuccess(t *testing.T) {
+	o, err := NewOnceErr([]ErrFuncType{func() error { return nil }})
+	assert.Equal(t, err, nil)
+	res, err := o.DoE()
+	assert.Equal(t, true, res)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, o.Done(false))
+	res, err = o.DoE()
+	assert.Equal(t, false, res)
+	assert.Equal(t, nil, err)
+
+	// options are applied over the defaults
+	_, err = NewOnceErr([]ErrFuncType{func() error { return nil }}, WithLazyDone(false))
+	assert.NotEqual(t, err, nil)
+	o, err = NewOnceErr([]ErrFuncType{func() error { return errors.New("failed") }}, WithVerify(VerifyNone))
+	assert.Equal(t, err, nil)
+	res, err = o.DoE()
+	assert.Equal(t, true, res)
+	assert.Equal(t, "failed", err.Error())
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestNewOnceErrParallel(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	o, err := NewOnceErr([]ErrFuncType{
+		func() error { return errA },
+		func() error { return nil },
+		func() error { return errB },
+		func() error { panic("c") },
+	}, WithParallel(), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+
+	res, err := o.DoE()
+	assert.Equal(t, false, res)
+	assert.True(t, errors.Is(err, errA))
+	assert.True(t, errors.Is(err, errB))
+	assert.True(t, errors.Is(err, ErrPanicked))
+	assert.Equal(t, false, o.Done(false))
+}
+
+func TestOnceErrClone(t *testing.T) {
+	errBoom := errors.New("boom")
+	fail := true
+	o, err := NewOnceErr([]ErrFuncType{func() error {
+		if fail {
+			return errBoom
+		}
+		return nil
+	}})
+	assert.Equal(t, err, nil)
+
+	c := o.Clone()
+	ok, err := c.DoE()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, errBoom, err)
+	assert.Equal(t, nil, o.Err())
+
+	fail = false
+	ok, err = o.DoE()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, errBoom, c.Err())
+
+	// functions replaced by Swap are not error wrappers anymore
+	_, err = c.Swap(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, c.Clone().Do())
+}
@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// OnceGroup coordinates the initialization of named Onces which depend on each other, e.g. the components of
+// a service. Each Once is executed exactly once by Run(), after all the Onces it depends on are DONE.
+// Clients should use NewOnceGroup to create objects.
+type OnceGroup struct {
+	mu          sync.Mutex
+	parallelism int
+	nodes       map[string]*onceGroupNode
+	names       []string // in the order of Add, so that Run() is deterministic with a parallelism of 1
+}
+
+type onceGroupNode struct {
+	o    *Once
+	deps []string
+}
+
+// NewOnceGroup returns an empty OnceGroup. Run() executes at most parallelism Onces at a time,
+// a parallelism of 0 or less means no limit.
+func NewOnceGroup(parallelism int) *OnceGroup {
+	return &OnceGroup{parallelism: parallelism, nodes: make(map[string]*onceGroupNode)}
+}
+
+// Add registers o under name, to be executed after the Onces registered under deps. The dependencies may be added
+// later, they are checked by Run(). An error is returned if o is nil or name is already registered.
+func (g *OnceGroup) Add(name string, o *Once, deps ...string) error {
+	if o == nil {
+		return fmt.Errorf("once for %q is nil", name)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("once %q is already added", name)
+	}
+	g.nodes[name] = &onceGroupNode{o: o, deps: append([]string{}, deps...)}
+	g.names = append(g.names, name)
+	return nil
+}
+
+// Done returns Done(false) of the Once registered under name, or false if there is none.
+func (g *OnceGroup) Done(name string) bool {
+	g.mu.Lock()
+	n, ok := g.nodes[name]
+	g.mu.Unlock()
+	return ok && n.o.Done(false)
+}
+
+// Run executes the Onces in dependency order using DoOrWait(), running independent ones concurrently up to the
+// parallelism of the group. A Once which isn't DONE after its execution, or reports an error, fails: the Onces
+// depending on it are not executed and Run returns an error for it once the Onces already executing have finished.
+// If ctx is done, Onces not started yet are not executed either and ctx.Err() is returned.
+// An error is returned without executing anything if a dependency isn't registered or the dependencies form a cycle.
+// Run can be called again, e.g. after a failure: the Onces already DONE are not executed again.
+func (g *OnceGroup) Run(ctx context.Context) error {
+	g.mu.Lock()
+	names := append([]string{}, g.names...)
+	nodes := make(map[string]*onceGroupNode, len(g.nodes))
+	for name, n := range g.nodes {
+		nodes[name] = n
+	}
+	g.mu.Unlock()
+
+	// pending counts the dependencies not DONE yet, dependents is the reverse of deps
+	pending := make(map[string]int, len(nodes))
+	dependents := make(map[string][]string, len(nodes))
+	for _, name := range names {
+		for _, dep := range nodes[name].deps {
+			if _, ok := nodes[dep]; !ok {
+				return fmt.Errorf("once %q depends on %q which is not added", name, dep)
+			}
+			pending[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	if err := checkCycles(names, pending, dependents); err != nil {
+		return err
+	}
+
+	type result struct {
+		name string
+		err  error
+	}
+	var (
+		ready   []string
+		running int
+		err     error
+		results = make(chan result)
+	)
+	for _, name := range names {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	for len(ready) > 0 || running > 0 {
+		// start as many as allowed, unless something failed already
+		for err == nil && len(ready) > 0 && (g.parallelism <= 0 || running < g.parallelism) {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			name := ready[0]
+			ready = ready[1:]
+			running++
+			go func(name string, o *Once) {
+				// DoOrWait, as with lazyDone = false Do() returns right away while another goroutine executes
+				o.DoOrWait()
+				err := o.Err()
+				if err == nil && !o.Done(false) {
+					err = ErrNotReady
+				}
+				results <- result{name, err}
+			}(name, nodes[name].o)
+		}
+		if running == 0 {
+			break
+		}
+
+		r := <-results
+		running--
+		if r.err != nil {
+			if err == nil {
+				err = fmt.Errorf("once %q failed: %w", r.name, r.err)
+			}
+			continue
+		}
+		for _, name := range dependents[r.name] {
+			if pending[name]--; pending[name] == 0 {
+				ready = append(ready, name)
+			}
+		}
+	}
+	return err
+}
+
+// checkCycles returns an error if the dependency graph has a cycle, by removing the nodes without pending
+// dependencies one by one till none is left. pending isn't modified.
+func checkCycles(names []string, pending map[string]int, dependents map[string][]string) error {
+	left := make(map[string]int, len(pending))
+	var free []string
+	for _, name := range names {
+		left[name] = pending[name]
+		if left[name] == 0 {
+			free = append(free, name)
+		}
+	}
+
+	removed := 0
+	for len(free) > 0 {
+		name := free[0]
+		free = free[1:]
+		removed++
+		for _, dependent := range dependents[name] {
+			if left[dependent]--; left[dependent] == 0 {
+				free = append(free, dependent)
+			}
+		}
+	}
+	if removed == len(names) {
+		return nil
+	}
+	for _, name := range names {
+		if left[name] > 0 {
+			return fmt.Errorf("dependencies of once %q form a cycle", name)
+		}
+	}
+	return nil
+}
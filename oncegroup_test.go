@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRecordingOnce returns a Once which appends name to order when executed.
+func newRecordingOnce(t *testing.T, mu *sync.Mutex, order *[]string, name string) *Once {
+	o, err := NewOnce(true, false, VerifyNone, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		*order = append(*order, name)
+		return true
+	})
+	assert.Equal(t, err, nil)
+	return o
+}
+
+func TestOnceGroupOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	g := NewOnceGroup(1)
+	assert.Equal(t, nil, g.Add("app", newRecordingOnce(t, &mu, &order, "app"), "db", "cache"))
+	assert.Equal(t, nil, g.Add("cache", newRecordingOnce(t, &mu, &order, "cache"), "config"))
+	assert.Equal(t, nil, g.Add("db", newRecordingOnce(t, &mu, &order, "db"), "config"))
+	assert.Equal(t, nil, g.Add("config", newRecordingOnce(t, &mu, &order, "config")))
+	assert.NotEqual(t, nil, g.Add("db", newRecordingOnce(t, &mu, &order, "db")))
+	assert.NotEqual(t, nil, g.Add("nil", nil))
+	assert.Equal(t, false, g.Done("config"))
+
+	assert.Equal(t, nil, g.Run(context.Background()))
+	assert.Equal(t, []string{"config", "cache", "db", "app"}, order)
+	for _, name := range []string{"app", "cache", "db", "config"} {
+		assert.Equal(t, true, g.Done(name))
+	}
+	assert.Equal(t, false, g.Done("unknown"))
+
+	// nothing is executed again
+	assert.Equal(t, nil, g.Run(context.Background()))
+	assert.Equal(t, 4, len(order))
+}
+
+func TestOnceGroupParallel(t *testing.T) {
+	// a and b can only finish if they execute at the same time
+	var wg sync.WaitGroup
+	wg.Add(2)
+	f := func() bool {
+		wg.Done()
+		wg.Wait()
+		return true
+	}
+	a, err := NewDefaultOnce(f)
+	assert.Equal(t, err, nil)
+	b, err := NewDefaultOnce(f)
+	assert.Equal(t, err, nil)
+
+	g := NewOnceGroup(2)
+	g.Add("a", a)
+	g.Add("b", b)
+	assert.Equal(t, nil, g.Run(context.Background()))
+}
+
+func TestOnceGroupFailure(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	ok := false
+	failing, err := NewOnce(true, false, VerifyAll, func() bool { return ok })
+	assert.Equal(t, err, nil)
+
+	g := NewOnceGroup(0)
+	g.Add("failing", failing)
+	g.Add("dependent", newRecordingOnce(t, &mu, &order, "dependent"), "failing")
+	g.Add("independent", newRecordingOnce(t, &mu, &order, "independent"))
+	err = g.Run(context.Background())
+	assert.True(t, errors.Is(err, ErrNotReady))
+	assert.Contains(t, err.Error(), `"failing"`)
+	assert.Equal(t, []string{"independent"}, order)
+	assert.Equal(t, false, g.Done("dependent"))
+
+	// the failed Once is retried by the next Run
+	ok = true
+	assert.Equal(t, nil, g.Run(context.Background()))
+	assert.Equal(t, []string{"independent", "dependent"}, order)
+}
+
+func TestOnceGroupInvalid(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	g := NewOnceGroup(0)
+	g.Add("a", newRecordingOnce(t, &mu, &order, "a"), "missing")
+	assert.NotEqual(t, nil, g.Run(context.Background()))
+
+	g = NewOnceGroup(0)
+	g.Add("root", newRecordingOnce(t, &mu, &order, "root"))
+	g.Add("a", newRecordingOnce(t, &mu, &order, "a"), "root", "b")
+	g.Add("b", newRecordingOnce(t, &mu, &order, "b"), "a")
+	assert.NotEqual(t, nil, g.Run(context.Background()))
+	assert.Equal(t, []string(nil), order)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g = NewOnceGroup(0)
+	g.Add("a", newRecordingOnce(t, &mu, &order, "a"))
+	assert.Equal(t, context.Canceled, g.Run(ctx))
+	assert.Equal(t, []string(nil), order)
+}
+
+func TestOnceGroupExternalExecution(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	// the dependency is already being executed by another goroutine when Run starts
+	release := make(chan struct{})
+	dep, err := NewDefaultOnce(func() bool {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, "dep")
+		return true
+	})
+	assert.Equal(t, err, nil)
+	go dep.Do()
+	dep.WaitReady()
+
+	g := NewOnceGroup(0)
+	g.Add("dep", dep)
+	g.Add("app", newRecordingOnce(t, &mu, &order, "app"), "dep")
+	done := make(chan error)
+	go func() { done <- g.Run(context.Background()) }()
+
+	// app must not start while dep is executing
+	time.Sleep(time.Millisecond * 5)
+	mu.Lock()
+	assert.Equal(t, []string(nil), order)
+	mu.Unlock()
+	close(release)
+	assert.Equal(t, nil, <-done)
+	assert.Equal(t, []string{"dep", "app"}, order)
+}
@@ -0,0 +1,115 @@
+package sync
+
+import "fmt"
+
+// OnceValue computes a value once and serves it to all callers, like OnceValue from golang's sync package,
+// with the extras of Once: Done, Close, Reset and the options e.g. panic suppression.
+// Clients should use NewOnceValue to create objects.
+type OnceValue[T any] struct {
+	o *Once
+}
+
+// NewOnceValue returns a OnceValue for f. opts are same as for NewOnceWithOptions except that lazyDone is always
+// true, so that all callers of Get() get the value computed by f.
+// If f panics, the panic is raised in the caller of Get() unless suppressed with WithSuppressPanic(true), in which
+// case Get() returns the zero value and Err() reports the panic. Either way the state doesn't become DONE.
+func NewOnceValue[T any](f func() T, opts ...Option) (*OnceValue[T], error) {
+	if f == nil {
+		return nil, fmt.Errorf("function at index 0 is nil")
+	}
+
+	v := &OnceValue[T]{}
+	o, err := NewOnceWithOptions([]FuncType{func() bool {
+		v.o.StoreValue(f())
+		return true
+	}}, append(opts[:len(opts):len(opts)], WithLazyDone(true))...)
+	if err != nil {
+		return nil, err
+	}
+	v.o = o
+	return v, nil
+}
+
+// Get calls f if it hasn't been called yet and returns the value it returned.
+// Concurrent callers stay blocked till f returns. The zero value is returned if f panicked or the OnceValue
+// was closed before f could run.
+func (v *OnceValue[T]) Get() T {
+	v.o.Do()
+	// the value is nil, not a T, if T is an interface type and f returned nil. The zero value is nil then too
+	val, _ := v.o.LoadValue()
+	t, _ := val.(T)
+	return t
+}
+
+// Done is same as Once.Done.
+func (v *OnceValue[T]) Done(block bool) bool {
+	return v.o.Done(block)
+}
+
+// Err is same as Once.Err.
+func (v *OnceValue[T]) Err() error {
+	return v.o.Err()
+}
+
+// Close is same as Once.Close.
+func (v *OnceValue[T]) Close() {
+	v.o.Close()
+}
+
+// Reset forgets the value, so that the next Get() calls f again. Otherwise it's same as Once.Reset.
+func (v *OnceValue[T]) Reset() bool {
+	return v.o.Reset()
+}
+
+// OnceValues is same as OnceValue for a function returning two values, typically a value and an error.
+// Clients should use NewOnceValues to create objects.
+type OnceValues[T1, T2 any] struct {
+	v *OnceValue[onceValues[T1, T2]]
+}
+
+type onceValues[T1, T2 any] struct {
+	v1 T1
+	v2 T2
+}
+
+// NewOnceValues returns a OnceValues for f. It's same as NewOnceValue otherwise.
+func NewOnceValues[T1, T2 any](f func() (T1, T2), opts ...Option) (*OnceValues[T1, T2], error) {
+	if f == nil {
+		return nil, fmt.Errorf("function at index 0 is nil")
+	}
+
+	v, err := NewOnceValue(func() onceValues[T1, T2] {
+		v1, v2 := f()
+		return onceValues[T1, T2]{v1, v2}
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &OnceValues[T1, T2]{v}, nil
+}
+
+// Get calls f if it hasn't been called yet and returns the values it returned. It's same as OnceValue.Get otherwise.
+func (v *OnceValues[T1, T2]) Get() (T1, T2) {
+	vs := v.v.Get()
+	return vs.v1, vs.v2
+}
+
+// Done is same as Once.Done.
+func (v *OnceValues[T1, T2]) Done(block bool) bool {
+	return v.v.Done(block)
+}
+
+// Err is same as Once.Err.
+func (v *OnceValues[T1, T2]) Err() error {
+	return v.v.Err()
+}
+
+// Close is same as Once.Close.
+func (v *OnceValues[T1, T2]) Close() {
+	v.v.Close()
+}
+
+// Reset is same as OnceValue.Reset.
+func (v *OnceValues[T1, T2]) Reset() bool {
+	return v.v.Reset()
+}
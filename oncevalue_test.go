@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnceValue(t *testing.T) {
+	_, err := NewOnceValue[int](nil)
+	assert.NotEqual(t, err, nil)
+
+	var calls int32
+	v, err := NewOnceValue(func() int {
+		time.Sleep(time.Millisecond * 2)
+		return int(atomic.AddInt32(&calls, 1)) * 10
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, v.Done(false))
+
+	var wg sync.WaitGroup
+	wg.Add(8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 10, v.Get())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, true, v.Done(false))
+
+	assert.Equal(t, true, v.Reset())
+	assert.Equal(t, 20, v.Get())
+
+	v.Close()
+	v.Reset()
+	v.Close()
+	assert.Equal(t, 0, v.Get())
+}
+
+func TestOnceValuePanic(t *testing.T) {
+	v, err := NewOnceValue(func() string { panic("boom") })
+	assert.Equal(t, err, nil)
+	assert.Panics(t, func() { v.Get() })
+	assert.Equal(t, false, v.Done(false))
+
+	v, err = NewOnceValue(func() string { panic("boom") }, WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "", v.Get())
+	assert.True(t, errors.Is(v.Err(), ErrPanicked))
+	assert.Equal(t, false, v.Done(false))
+}
+
+func TestOnceValues(t *testing.T) {
+	_, err := NewOnceValues[int, error](nil)
+	assert.NotEqual(t, err, nil)
+
+	calls := 0
+	v, err := NewOnceValues(func() (int, error) {
+		calls++
+		return strconv.Atoi("42")
+	})
+	assert.Equal(t, err, nil)
+	n, err := v.Get()
+	assert.Equal(t, 42, n)
+	assert.Equal(t, nil, err)
+	n, err = v.Get()
+	assert.Equal(t, 42, n)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, true, v.Done(true))
+	assert.Equal(t, nil, v.Err())
+
+	v, err = NewOnceValues(func() (int, error) { return strconv.Atoi("x") })
+	assert.Equal(t, err, nil)
+	_, err = v.Get()
+	assert.NotEqual(t, nil, err)
+
+	v.Reset()
+	v.Close()
+	n, err = v.Get()
+	assert.Equal(t, 0, n)
+	assert.Equal(t, nil, err)
+}
+
+func TestOnceValueNilInterface(t *testing.T) {
+	v, err := NewOnceValue(func() error { return nil })
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { assert.Nil(t, v.Get()) })
+	assert.Equal(t, true, v.Done(false))
+
+	errBoom := errors.New("boom")
+	v, err = NewOnceValue(func() error { return errBoom })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, errBoom, v.Get())
+}
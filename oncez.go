@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OnceZ is a Once which is usable as a zero value, similar to the Once from golang's sync package.
+//
+//	var o sync.OnceZ
+//	o.Do(f)
+//
+// Unlike Once, the function is given to Do() at call time and not while creating the object.
+// Same as the Once of golang's sync package, no call to Do() returns before f has returned, and the state is set
+// to DONE when f returns, even if it panics. Panics are not suppressed.
+// A OnceZ must not be copied after first use.
+type OnceZ struct {
+	mu          sync.Mutex
+	done        uint32
+	unblock     uint32
+	condInit    sync.Once // guards the lazy initialization of unblockCond
+	unblockCond *sync.Cond
+	doneCh      chan struct{} // returned by DoneChan till DONE or closed. guarded by unblockCond.L
+}
+
+// cond returns the Cond used to signal blocking clients, initializing it on first use.
+func (o *OnceZ) cond() *sync.Cond {
+	o.condInit.Do(func() {
+		o.unblockCond = sync.NewCond(&sync.Mutex{})
+	})
+	return o.unblockCond
+}
+
+// Do calls f if Do() hasn't been called before or the OnceZ was Reset.
+// Concurrent callers stay blocked till the one calling f finishes. As a consequence, calling Do() from f deadlocks.
+// Only the goroutine which called f gets `true` returned by Do(), all others get a false.
+// The return value of f is ignored.
+func (o *OnceZ) Do(f FuncType) bool {
+	// fast path: if already done, no need to lock
+	if atomic.LoadUint32(&o.done) == 1 {
+		return false
+	}
+
+	// slow path: lock and call function once
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if atomic.LoadUint32(&o.done) == 1 {
+		return false
+	}
+
+	// signal all waiting goroutines once DONE is set. DONE is set only after f returns, so the fast path can't
+	// return while f is executing
+	defer o.broadcast()
+	defer atomic.StoreUint32(&o.done, 1)
+
+	f()
+	return true
+}
+
+// DoFunc is same as Do() for a function with the signature used by the Once of golang's sync package,
+// so that OnceZ can replace it without wrapping the function.
+func (o *OnceZ) DoFunc(f func()) bool {
+	return o.Do(func() bool {
+		f()
+		return true
+	})
+}
+
+// Done returns if the OnceZ is in DONE state. It behaves same as Once.Done.
+func (o *OnceZ) Done(block bool) bool {
+	if block {
+		// state is checked while holding the Cond's lock so that a broadcast can't be missed
+		c := o.cond()
+		c.L.Lock()
+		for atomic.LoadUint32(&o.unblock) == 0 && atomic.LoadUint32(&o.done) == 0 {
+			c.Wait()
+		}
+		c.L.Unlock()
+	}
+
+	return atomic.LoadUint32(&o.done) == 1
+}
+
+// Wait blocks same as Done(true).
+func (o *OnceZ) Wait() {
+	o.Done(true)
+}
+
+// DoneChan returns a channel which is closed when the OnceZ becomes DONE or is closed, same as when Done(true)
+// returns. After Reset(), DoneChan returns a new channel.
+func (o *OnceZ) DoneChan() <-chan struct{} {
+	c := o.cond()
+	c.L.Lock()
+	defer c.L.Unlock()
+	if atomic.LoadUint32(&o.unblock) == 1 || atomic.LoadUint32(&o.done) == 1 {
+		return closedCh
+	}
+	if o.doneCh == nil {
+		o.doneCh = make(chan struct{})
+	}
+	return o.doneCh
+}
+
+// Reset resets OnceZ for reuse. It behaves same as Once.Reset.
+func (o *OnceZ) Reset() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	atomic.StoreUint32(&o.unblock, 0)
+	res := atomic.LoadUint32(&o.done) == 1
+	atomic.StoreUint32(&o.done, 0)
+	return res
+}
+
+// Close() unblocks all goroutines waiting on Done(true)
+func (o *OnceZ) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	atomic.StoreUint32(&o.unblock, 1)
+	o.broadcast()
+}
+
+// broadcast wakes up all goroutines blocked in Done(true) and closes the channel returned by DoneChan.
+func (o *OnceZ) broadcast() {
+	c := o.cond()
+	c.L.Lock()
+	c.Broadcast()
+	if o.doneCh != nil {
+		close(o.doneCh)
+		o.doneCh = nil
+	}
+	c.L.Unlock()
+}
@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnceZZeroValue(t *testing.T) {
+	var o OnceZ
+	executed := 0
+	f := func() bool { executed++; return false }
+
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do(f))
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, false, o.Do(f))
+	assert.Equal(t, 1, executed)
+
+	assert.Equal(t, true, o.Reset())
+	assert.Equal(t, false, o.Done(false))
+	assert.Equal(t, true, o.Do(f))
+	assert.Equal(t, 2, executed)
+}
+
+func TestOnceZConcurrentFirstUse(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		var o OnceZ
+		var executed, winners int32
+		f := func() bool { atomic.AddInt32(&executed, 1); return true }
+
+		var wg sync.WaitGroup
+		wg.Add(10)
+		for j := 0; j < 5; j++ {
+			go func() {
+				if o.Do(f) {
+					atomic.AddInt32(&winners, 1)
+				}
+				wg.Done()
+			}()
+			go func() { assert.Equal(t, true, o.Done(true)); wg.Done() }()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), executed)
+		assert.Equal(t, int32(1), winners)
+	}
+}
+
+func TestOnceZBlockingDoneAndClose(t *testing.T) {
+	var o OnceZ
+	ts := time.Now()
+	go func() { assert.Equal(t, true, o.Do(returnTrueWithDelay(time.Millisecond*4))) }()
+	assert.Equal(t, true, o.Done(true))
+	assert.True(t, time.Now().Sub(ts) > time.Millisecond*4)
+
+	var c OnceZ
+	go func() { time.Sleep(time.Millisecond * 2); c.Close() }()
+	assert.Equal(t, false, c.Done(true))
+}
+
+func TestOnceZDoFunc(t *testing.T) {
+	type service struct {
+		init  OnceZ
+		ready bool
+	}
+	var s service
+	executed := 0
+	f := func() { executed++; s.ready = true }
+
+	assert.Equal(t, true, s.init.DoFunc(f))
+	assert.Equal(t, false, s.init.DoFunc(f))
+	assert.Equal(t, false, s.init.Do(func() bool { executed++; return true }))
+	assert.Equal(t, 1, executed)
+	assert.Equal(t, true, s.ready)
+	assert.Equal(t, true, s.init.Done(true))
+}
+
+func TestOnceZDoWaitsForF(t *testing.T) {
+	var o OnceZ
+	var finished int32
+	started := make(chan struct{})
+	go o.DoFunc(func() {
+		close(started)
+		time.Sleep(time.Millisecond * 5)
+		atomic.StoreInt32(&finished, 1)
+	})
+	<-started
+
+	// same as sync.Once, a caller losing the race returns only after f has returned
+	assert.Equal(t, false, o.Do(returnTrue))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestOnceZPanic(t *testing.T) {
+	var o OnceZ
+	assert.Panics(t, func() { o.DoFunc(func() { panic("boom") }) })
+	assert.Equal(t, true, o.Done(true))
+	assert.Equal(t, false, o.Do(returnTrue))
+}
@@ -0,0 +1,152 @@
+package sync
+
+import "time"
+
+// Option configures a Once created with NewOnceWithOptions.
+type Option func(*Once)
+
+// WithLazyDone is the option form of the lazyDone parameter of NewOnce.
+func WithLazyDone(lazyDone bool) Option {
+	return func(d *Once) { d.lazyDone = lazyDone }
+}
+
+// WithSuppressPanic is the option form of the suppressPanic parameter of NewOnce.
+func WithSuppressPanic(suppressPanic bool) Option {
+	return func(d *Once) { d.suppressPanic = suppressPanic }
+}
+
+// WithPanicHandler makes Once suppress the panics of the function/s, same as WithSuppressPanic(true),
+// and call h with the value of each panic it recovers, e.g. to log it. h is called by the goroutine executing
+// the function/s, before the panic is recorded in Err(). Use PanicInfo() to get the recovered value and stack later.
+func WithPanicHandler(h PanicHandler) Option {
+	return func(d *Once) {
+		d.suppressPanic = true
+		d.panicHandler = h
+	}
+}
+
+// WithVerify is the option form of the verify parameter of NewOnce.
+func WithVerify(verify VerifyType) Option {
+	return func(d *Once) { d.verify = verify }
+}
+
+// WithProgress sets a callback which Do() invokes after each function of the Once completes.
+// completed is the number of functions executed so far and total is the number of functions in the Once.
+//
+// The callback is only invoked by the goroutine which is actually executing the functions, in between the functions.
+// It should be fast and non-blocking as it delays the remaining functions and all goroutines blocked on Do().
+// Functions skipped because of the verify option are not reported.
+func WithProgress(progress func(completed, total int)) Option {
+	return func(d *Once) { d.progress = progress }
+}
+
+// WithStrict makes the Once panic on misuse instead of returning an error or silently doing nothing.
+// It is meant to catch bugs during development. Below are treated as misuse:
+//   - creating the Once with a nil function
+//   - calling Do() or any of its variants after Close()
+//   - calling Reset() while the function/s are being executed
+//   - calling Swap() while the function/s are being executed, or with a nil function
+func WithStrict() Option {
+	return func(d *Once) { d.strict = true }
+}
+
+// WithResetAfter makes the Once Reset() itself automatically after d has passed since it became DONE.
+// So the next Do() after that executes the function/s again, while calls to Do() within d share the earlier execution.
+// This is useful for values which need to be refreshed periodically, e.g. a token valid for some time.
+// A pending automatic reset is cancelled by an explicit Reset() and skipped if the Once is closed.
+func WithResetAfter(d time.Duration) Option {
+	return func(o *Once) { o.resetAfter = d }
+}
+
+// WithClock makes the Once take the time from c instead of the time package: for the automatic reset of
+// WithResetAfter, the backoff of WithRetry, DoWithTimeout() and Duration(). Tests use it with a ManualClock so as
+// not to sleep.
+func WithClock(c Clock) Option {
+	return func(d *Once) { d.clock = c }
+}
+
+// WithParallel makes Do() execute all the functions concurrently and wait for all of them to finish,
+// instead of executing them one after another. Each function has its own recover, so a panic in one function
+// doesn't stop the others. All the panics are combined into one error, which is available from Err()
+// when suppressPanic = true, or raised as a panic once all the functions have finished otherwise.
+// The errors of the functions of a Once created with NewOnceErr are combined in Err() too.
+// VerifyFirstExit behaves same as VerifyFirstRunAll in parallel mode as no function can be skipped.
+func WithParallel() Option {
+	return func(d *Once) { d.parallel = true }
+}
+
+// WithDoWrapper sets a function which wraps the execution of all the functions of the Once.
+// wrapper must call next exactly once, that is where the functions get executed.
+// It's invoked only by the goroutine executing the functions, once per execution, which makes it suitable
+// for instrumentation e.g. starting a tracing span before calling next and ending it after.
+// Panics from the functions propagate through wrapper, so its deferred calls run, and are then suppressed
+// or raised same as without a wrapper.
+func WithDoWrapper(wrapper func(next func())) Option {
+	return func(d *Once) { d.wrapper = wrapper }
+}
+
+// WithLockObserver makes Do() and its variants report every acquisition of the lock executing the function/s to
+// observer, named name, same as an InstrumentedMutex does. A goroutine which finds the function/s being executed
+// waits for that lock, so the events tell how long callers wait for an execution and how long executions hold it.
+// Only calls which get past the fast path i.e. find the Once not DONE take the lock.
+func WithLockObserver(name string, observer LockObserver) Option {
+	return func(d *Once) {
+		d.lockName = name
+		d.lockObserver = observer
+	}
+}
+
+// WithRetry makes the goroutine executing the function/s retry a failed execution, up to attempts executions in total.
+// An execution fails if it returns false as per the verify option, or if it panics and WithRetryOnPanic is used.
+// backoff returns the delay before the next attempt, given the number of the failed attempt starting from 1.
+// A nil backoff retries immediately.
+//
+// Goroutines blocked on Do() stay blocked across the retries. If the last attempt fails too, the Once is left
+// as that attempt leaves it, e.g. not DONE when lazyDone = true, and Err() reports its error.
+// Retries only decide when DONE gets set if lazyDone = true.
+func WithRetry(attempts int, backoff func(attempt int) time.Duration) Option {
+	return func(d *Once) {
+		d.retryAttempts = attempts
+		d.retryBackoff = backoff
+	}
+}
+
+// WithRetryOnPanic makes WithRetry retry executions which panic as well.
+// Panics of all but the last attempt are recovered and don't reach the caller, irrespective of suppressPanic.
+func WithRetryOnPanic() Option {
+	return func(d *Once) { d.retryOnPanic = true }
+}
+
+// WithFIFOWaiters makes goroutines blocked on Done(true), WaitReady() and the like get released in the order they
+// started waiting, on completion or Close(). Without it, the order in which the waiters wake up is unspecified.
+// A waiter is woken up only after the waiters ahead of it have been, so releasing many waiters takes longer.
+func WithFIFOWaiters() Option {
+	return func(d *Once) { d.fifo = true }
+}
+
+// failureMode decides the state a failed execution leaves the Once in. See WithStickyFailure.
+type failureMode int
+
+const (
+	failureDefault   failureMode = iota // DONE is set as per lazyDone and verify
+	failureSticky                       // a failed execution sets DONE
+	failureRetryable                    // a failed execution never leaves DONE set
+)
+
+// WithStickyFailure decides whether a failed execution leaves the Once DONE. An execution fails if it panics,
+// returns false as per the verify option, or records an error in Err() e.g. a suppressed panic or an error from
+// a function of NewOnceErr.
+//
+// If sticky = true, a failed execution sets DONE so the function/s are never executed again till Reset().
+// If sticky = false, a failed execution never leaves DONE set, even if lazyDone = false, so the next Do() retries.
+// With lazyDone = false, DONE is still set while the function/s execute and is reverted if they fail.
+// Without this option, a failure is sticky if lazyDone = false, and retryable if lazyDone = true.
+func WithStickyFailure(sticky bool) Option {
+	return func(d *Once) {
+		if sticky {
+			d.failure = failureSticky
+		} else {
+			d.failure = failureRetryable
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrPanicked is matched by errors.Is for errors recorded from a panic in the function/s of a Once.
+var ErrPanicked = errors.New("function panicked")
+
+// PanicError is the error recorded when a function of a Once panics and the panic is suppressed.
+// Use errors.As to get the panic value and the stack of the goroutine which panicked.
+type PanicError struct {
+	Value interface{} // value passed to panic()
+	Stack []byte      // stack trace captured while recovering
+}
+
+// newPanicError returns a PanicError for the recovered value p. It must be called from the deferred recover function
+// so that the stack of the panicking goroutine is captured.
+func newPanicError(p interface{}) *PanicError {
+	return &PanicError{Value: p, Stack: debug.Stack()}
+}
+
+// PanicHandler is called with the value passed to panic() when a panic in the function/s of a Once is recovered.
+// See WithPanicHandler.
+type PanicHandler func(recovered interface{})
+
+// recovered returns a PanicError for the recovered value p after passing p to the PanicHandler, if any.
+// Same as newPanicError, it must be called from the deferred recover function.
+func (d *Once) recovered(p interface{}) *PanicError {
+	e := newPanicError(p)
+	if d.panicHandler != nil {
+		d.panicHandler(p)
+	}
+	return e
+}
+
+// PanicInfo returns the panic recorded by the latest execution in the current generation, with the stack of the
+// goroutine which panicked, or nil if there was none. Only suppressed panics are recorded. Reset() clears it.
+// In parallel mode, where several functions can panic, the first one recorded is returned.
+func (d *Once) PanicInfo() *PanicError {
+	var e *PanicError
+	if errors.As(d.Err(), &e) {
+		return e
+	}
+	return nil
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Is makes errors.Is(err, ErrPanicked) true for a PanicError.
+func (e *PanicError) Is(target error) bool {
+	return target == ErrPanicked
+}
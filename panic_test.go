@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanicError(t *testing.T) {
+	o, err := NewOnce(false, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+	o.Do()
+
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+	var pe *PanicError
+	assert.True(t, errors.As(o.Err(), &pe))
+	assert.Equal(t, 1, pe.Value)
+	assert.Contains(t, string(pe.Stack), "doPanic")
+	assert.Equal(t, "panic: 1", pe.Error())
+
+	assert.False(t, errors.Is(errors.New("other"), ErrPanicked))
+}
+
+func TestPanicErrorParallel(t *testing.T) {
+	o, err := NewOnceWithOptions([]FuncType{returnTrue, doPanic, func() bool { panic("two") }}, WithParallel(), WithSuppressPanic(true))
+	assert.Equal(t, err, nil)
+	o.Do()
+
+	assert.True(t, errors.Is(o.Err(), ErrPanicked))
+	var values []interface{}
+	for _, e := range o.Err().(interface{ Unwrap() []error }).Unwrap() {
+		var pe *PanicError
+		assert.True(t, errors.As(e, &pe))
+		values = append(values, pe.Value)
+	}
+	assert.ElementsMatch(t, []interface{}{1, "two"}, values)
+}
+
+func TestPanicHandler(t *testing.T) {
+	var values []interface{}
+	o, err := NewOnceWithOptions([]FuncType{doPanic}, WithLazyDone(true), WithPanicHandler(func(p interface{}) {
+		values = append(values, p)
+	}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, (*PanicError)(nil), o.PanicInfo())
+
+	assert.NotPanics(t, func() { o.Do() })
+	assert.Equal(t, []interface{}{1}, values)
+	info := o.PanicInfo()
+	assert.NotEqual(t, nil, info)
+	assert.Equal(t, 1, info.Value)
+	assert.Contains(t, string(info.Stack), "doPanic")
+
+	// the execution failed, so the next Do() executes again
+	o.Do()
+	assert.Equal(t, []interface{}{1, 1}, values)
+
+	o.Reset()
+	assert.Equal(t, (*PanicError)(nil), o.PanicInfo())
+}
+
+func TestPanicHandlerParallel(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		values []interface{}
+	)
+	o, err := NewOnceWithOptions([]FuncType{doPanic, returnTrue, doPanic}, WithParallel(), WithPanicHandler(func(p interface{}) {
+		mu.Lock()
+		values = append(values, p)
+		mu.Unlock()
+	}))
+	assert.Equal(t, err, nil)
+	assert.NotPanics(t, func() { o.Do() })
+	assert.Equal(t, []interface{}{1, 1}, values)
+	assert.Equal(t, 1, o.PanicInfo().Value)
+}
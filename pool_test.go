@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPool(t *testing.T) {
+	_, err := NewPool[int](nil, -1)
+	assert.NotEqual(t, err, nil)
+
+	p, err := NewPool[int](nil, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 0, p.Get())
+	assert.Equal(t, PoolStats{Misses: 1}, p.Stats())
+}
+
+func TestPool(t *testing.T) {
+	n := 0
+	p, err := NewPool(func() *int {
+		n++
+		v := n
+		return &v
+	}, 2)
+	assert.Equal(t, err, nil)
+
+	a, b, c := p.Get(), p.Get(), p.Get()
+	assert.Equal(t, 3, n)
+	assert.Equal(t, true, p.Put(a))
+	assert.Equal(t, true, p.Put(b))
+	assert.Equal(t, false, p.Put(c))
+	assert.Equal(t, 2, p.Len())
+
+	// LIFO
+	assert.Equal(t, b, p.Get())
+	assert.Equal(t, a, p.Get())
+	assert.Equal(t, 4, *p.Get())
+	assert.Equal(t, PoolStats{Hits: 2, Misses: 4, Creates: 4, Discards: 1}, p.Stats())
+}
+
+func TestPoolConcurrent(t *testing.T) {
+	p, _ := NewPool(func() []byte { return make([]byte, 8) }, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.Put(p.Get())
+			}
+		}()
+	}
+	wg.Wait()
+	s := p.Stats()
+	assert.Equal(t, uint64(800), s.Hits+s.Misses)
+	assert.Equal(t, s.Misses, s.Creates)
+	assert.Equal(t, s.Creates, uint64(s.Idle)+s.Discards)
+	assert.LessOrEqual(t, s.Idle, 4)
+}
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// processOncePoll is how often Done(true) and DoneContext check for the marker file of a ProcessOnce.
+const processOncePoll = 10 * time.Millisecond
+
+// ProcessOnce executes a function once per host, across all the processes using the same marker path, e.g. to run
+// a migration once while several replicas start on the same machine. The processes coordinate through an advisory
+// lock on the file path+".lock": the one which gets the lock first executes the function and, if it returns true,
+// creates the marker file at path. The others wait for the lock and find the marker. Removing the marker file
+// re-arms it. File locks are supported on linux and the BSDs, including darwin, only.
+// Clients should use NewProcessOnce to create objects.
+type ProcessOnce struct {
+	path string
+	f    FuncType
+	mu   sync.Mutex // serializes the goroutines of this process, the file lock serializes the processes
+}
+
+// NewProcessOnce returns a ProcessOnce executing f, using the marker file path. An error is returned if path is
+// empty or f is nil.
+func NewProcessOnce(path string, f FuncType) (*ProcessOnce, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path can't be empty")
+	}
+	if f == nil {
+		return nil, fmt.Errorf("function can't be nil")
+	}
+	return &ProcessOnce{path: path, f: f}, nil
+}
+
+// Do executes the function if no process has completed it yet, blocking while another goroutine or process is
+// executing it. The marker is created only if the function returns true, otherwise the next Do() executes it again.
+// Do returns true for the caller which executed the function, and an error if the lock or marker files can't be
+// used, in which case the function isn't executed.
+// If the function panics, the lock is released and the panic is raised in the caller.
+func (p *ProcessOnce) Do() (bool, error) {
+	if done, err := p.marked(); done || err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	unlock, err := lockFile(p.path + ".lock")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// another process may have executed the function while this one waited for the lock
+	if done, err := p.marked(); done || err != nil {
+		return false, err
+	}
+	if !p.f() {
+		return true, nil
+	}
+	return true, p.mark()
+}
+
+// Done returns whether the function has been completed by any process. If block = true, it blocks till it has.
+// Since other processes can't signal this one, blocking polls the marker file.
+func (p *ProcessOnce) Done(block bool) bool {
+	if !block {
+		done, _ := p.marked()
+		return done
+	}
+	return p.DoneContext(context.Background())
+}
+
+// DoneContext blocks till the function has been completed by any process and returns true, or returns false
+// if ctx is done first.
+func (p *ProcessOnce) DoneContext(ctx context.Context) bool {
+	t := time.NewTicker(processOncePoll)
+	defer t.Stop()
+	for {
+		if done, _ := p.marked(); done {
+			return true
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// marked returns whether the marker file exists.
+func (p *ProcessOnce) marked() (bool, error) {
+	_, err := os.Stat(p.path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// mark creates the marker file, syncing it so that it survives a crash right after the execution.
+func (p *ProcessOnce) mark() error {
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
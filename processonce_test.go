@@ -0,0 +1,115 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProcessOnce(t *testing.T) {
+	_, err := NewProcessOnce("", returnTrue)
+	assert.NotEqual(t, err, nil)
+	_, err = NewProcessOnce(filepath.Join(t.TempDir(), "done"), nil)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestProcessOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrated")
+	calls := 0
+	ok := false
+	p, err := NewProcessOnce(path, func() bool {
+		calls++
+		return ok
+	})
+	assert.Equal(t, err, nil)
+
+	// not marked when the function fails
+	ran, err := p.Do()
+	assert.Equal(t, true, ran)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, p.Done(false))
+
+	ok = true
+	ran, err = p.Do()
+	assert.Equal(t, true, ran)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, p.Done(false))
+
+	ran, err = p.Do()
+	assert.Equal(t, false, ran)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, calls)
+
+	// another ProcessOnce on the same path, as in another process, sees the marker
+	other, _ := NewProcessOnce(path, func() bool {
+		calls++
+		return true
+	})
+	ran, _ = other.Do()
+	assert.Equal(t, false, ran)
+	assert.Equal(t, 2, calls)
+
+	// removing the marker re-arms it
+	assert.Equal(t, nil, os.Remove(path))
+	ran, _ = other.Do()
+	assert.Equal(t, true, ran)
+	assert.Equal(t, 3, calls)
+}
+
+func TestProcessOnceConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "done")
+	var calls int32
+	f := func() bool {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(5 * time.Millisecond)
+		return true
+	}
+	// separate ProcessOnces coordinate through the file lock only
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		p, _ := NewProcessOnce(path, f)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Do()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, p.Done(false))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestProcessOnceDoneContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "done")
+	p, _ := NewProcessOnce(path, returnTrue)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, false, p.DoneContext(ctx))
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		p.Do()
+	}()
+	assert.Equal(t, true, p.Done(true))
+}
+
+func TestProcessOncePanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "done")
+	p, _ := NewProcessOnce(path, func() bool { panic("boom") })
+	assert.Panics(t, func() { p.Do() })
+
+	// the lock was released
+	other, _ := NewProcessOnce(path, returnTrue)
+	ran, err := other.Do()
+	assert.Equal(t, true, ran)
+	assert.Equal(t, nil, err)
+}
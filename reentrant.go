@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// ReentrantMutex is a recursive mutual exclusion lock: the goroutine holding it can lock it again, and it's
+// unlocked once Unlock() has been called as many times as Lock(). Unlike Mutex, it must be unlocked by the
+// goroutine which locked it. Goroutines are told apart by their id, which is parsed from the runtime's stack
+// trace, so locking is slower than for Mutex. Its zero value is an unlocked mutex.
+// A ReentrantMutex must not be copied after first use.
+type ReentrantMutex struct {
+	mu    Mutex
+	owner int64 // id of the goroutine holding mu, 0 if none
+	depth int   // number of Lock() calls not unlocked yet. only accessed by the owner
+}
+
+// Lock locks m, blocking till it's available, unless the calling goroutine holds it already.
+func (m *ReentrantMutex) Lock() {
+	if m.reenter() {
+		return
+	}
+	m.mu.Lock()
+	m.acquired()
+}
+
+// TryLock locks m if it's available right away or held by the calling goroutine, and returns whether it did.
+func (m *ReentrantMutex) TryLock() bool {
+	if m.reenter() {
+		return true
+	}
+	if !m.mu.TryLock() {
+		return false
+	}
+	m.acquired()
+	return true
+}
+
+// LockCtx locks m, blocking till it's available or ctx is done, unless the calling goroutine holds it already.
+// It returns nil if m was locked, else ctx.Err().
+func (m *ReentrantMutex) LockCtx(ctx context.Context) error {
+	if m.reenter() {
+		return nil
+	}
+	if err := m.mu.LockCtx(ctx); err != nil {
+		return err
+	}
+	m.acquired()
+	return nil
+}
+
+// Unlock undoes one Lock() of m, unlocking it when it undoes the first one.
+// Unlock panics if m isn't held by the calling goroutine.
+func (m *ReentrantMutex) Unlock() {
+	if !m.HeldByCurrent() {
+		panic("sync: unlock of reentrant mutex not held by the current goroutine")
+	}
+	m.depth--
+	if m.depth == 0 {
+		atomic.StoreInt64(&m.owner, 0)
+		m.mu.Unlock()
+	}
+}
+
+// HeldByCurrent returns whether m is held by the calling goroutine.
+func (m *ReentrantMutex) HeldByCurrent() bool {
+	return atomic.LoadInt64(&m.owner) == goid()
+}
+
+// reenter locks m again if it's held by the calling goroutine and returns whether it did.
+func (m *ReentrantMutex) reenter() bool {
+	if !m.HeldByCurrent() {
+		return false
+	}
+	m.depth++
+	return true
+}
+
+// acquired records the calling goroutine as the owner once it has locked mu.
+func (m *ReentrantMutex) acquired() {
+	atomic.StoreInt64(&m.owner, goid())
+	m.depth = 1
+}
+
+// goid returns the id of the calling goroutine, parsed from the first line of its stack trace,
+// which looks like "goroutine 18 [running]:".
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		panic("sync: can't parse goroutine id: " + err.Error())
+	}
+	return id
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoid(t *testing.T) {
+	id := goid()
+	assert.Greater(t, id, int64(0))
+	assert.Equal(t, id, goid())
+
+	other := make(chan int64)
+	go func() { other <- goid() }()
+	assert.NotEqual(t, id, <-other)
+}
+
+func TestReentrantMutex(t *testing.T) {
+	var m ReentrantMutex
+	assert.Equal(t, false, m.HeldByCurrent())
+	assert.Panics(t, func() { m.Unlock() })
+
+	m.Lock()
+	m.Lock()
+	assert.Equal(t, true, m.TryLock())
+	assert.Equal(t, nil, m.LockCtx(context.Background()))
+	assert.Equal(t, true, m.HeldByCurrent())
+
+	locked := make(chan bool)
+	go func() {
+		assert.Equal(t, false, m.HeldByCurrent())
+		assert.Panics(t, func() { m.Unlock() })
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, m.LockCtx(ctx))
+		locked <- m.TryLock()
+	}()
+	assert.Equal(t, false, <-locked)
+
+	for i := 0; i < 3; i++ {
+		m.Unlock()
+		assert.Equal(t, true, m.HeldByCurrent())
+	}
+	m.Unlock()
+	assert.Equal(t, false, m.HeldByCurrent())
+
+	go func() {
+		locked <- m.TryLock()
+		m.Unlock()
+	}()
+	assert.Equal(t, true, <-locked)
+}
+
+func TestReentrantMutexConcurrent(t *testing.T) {
+	var m ReentrantMutex
+	var wg sync.WaitGroup
+	n := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Lock()
+				m.Lock()
+				n++
+				m.Unlock()
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 800, n)
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// RefCount shares a resource between holders and closes it once the last holder releases it. It starts with one
+// reference held by its creator. Close() of the resource is enforced to run exactly once by a Once, when the count
+// gets to zero and never before. After that the RefCount can't be acquired again.
+// Clients should use NewRefCount to create objects. A RefCount must not be copied after first use.
+type RefCount[T io.Closer] struct {
+	noCopy noCopy
+	v      T
+	count  int64
+	o      *Once
+	err    error // error of v.Close(), read once o is DONE
+}
+
+// NewRefCount returns a RefCount for v holding one reference, to be released by the caller.
+func NewRefCount[T io.Closer](v T) *RefCount[T] {
+	// lazyDone = true, so the RefCount becomes DONE only after the error of Close is stored
+	o, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	return &RefCount[T]{v: v, count: 1, o: o}
+}
+
+// Acquire takes a reference and returns the resource. It returns ErrClosed if the count got to zero already,
+// in which case the resource is closed or being closed and no reference is taken.
+func (r *RefCount[T]) Acquire() (T, error) {
+	for {
+		c := atomic.LoadInt64(&r.count)
+		if c == 0 {
+			var zero T
+			return zero, ErrClosed
+		}
+		if atomic.CompareAndSwapInt64(&r.count, c, c+1) {
+			return r.v, nil
+		}
+	}
+}
+
+// Release drops a reference. If it was the last one, Release closes the resource and returns the error of its
+// Close(), else it returns nil. Release panics if called more times than references were taken,
+// same as a negative sync.WaitGroup counter.
+func (r *RefCount[T]) Release() error {
+	c := atomic.AddInt64(&r.count, -1)
+	if c < 0 {
+		atomic.AddInt64(&r.count, 1)
+		panic("sync: refcount released more than acquired")
+	}
+	if c > 0 {
+		return nil
+	}
+	r.o.DoAlso(func() bool {
+		r.err = r.v.Close()
+		return true
+	})
+	return r.err
+}
+
+// Count returns the number of references held.
+func (r *RefCount[T]) Count() int {
+	return int(atomic.LoadInt64(&r.count))
+}
+
+// Closed returns whether the resource has been closed. If block = true, it blocks till it has.
+func (r *RefCount[T]) Closed(block bool) bool {
+	return r.o.Done(block)
+}
+
+// WaitClosed blocks till the resource has been closed and returns the error of its Close(). It returns ctx.Err()
+// if ctx is done first.
+func (r *RefCount[T]) WaitClosed(ctx context.Context) error {
+	if !r.o.DoneContext(ctx) {
+		return ctx.Err()
+	}
+	return r.err
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingCloser struct {
+	closes int32
+	err    error
+}
+
+func (c *countingCloser) Close() error {
+	atomic.AddInt32(&c.closes, 1)
+	return c.err
+}
+
+func TestRefCount(t *testing.T) {
+	errClose := errors.New("close failed")
+	c := &countingCloser{err: errClose}
+	r := NewRefCount(c)
+
+	v, err := r.Acquire()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, c, v)
+	assert.Equal(t, 2, r.Count())
+
+	assert.Equal(t, nil, r.Release())
+	assert.Equal(t, false, r.Closed(false))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&c.closes))
+
+	assert.Equal(t, errClose, r.Release())
+	assert.Equal(t, true, r.Closed(false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.closes))
+	assert.Equal(t, errClose, r.WaitClosed(context.Background()))
+
+	_, err = r.Acquire()
+	assert.Equal(t, ErrClosed, err)
+	assert.Panics(t, func() { r.Release() })
+}
+
+func TestRefCountConcurrent(t *testing.T) {
+	c := &countingCloser{}
+	r := NewRefCount(c)
+	closed := make(chan bool)
+	go func() { closed <- r.Closed(true) }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := r.Acquire(); err != nil {
+					return
+				}
+				assert.Equal(t, int32(0), atomic.LoadInt32(&c.closes))
+				r.Release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, r.WaitClosed(ctx))
+
+	r.Release()
+	assert.Equal(t, true, <-closed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&c.closes))
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RefOnce is a reference counted Once. The init function runs on the first Acquire() and the teardown function
+// runs when the last reference is released, after which the next Acquire() runs init again.
+// This is the singleton-with-lifecycle pattern. Clients should use NewRefOnce to create objects.
+type RefOnce struct {
+	mu       sync.Mutex
+	refs     int
+	init     *Once
+	teardown *Once
+}
+
+// NewRefOnce returns a RefOnce for the given init and teardown functions. The return values of the functions are ignored.
+func NewRefOnce(initFn, teardownFn FuncType) (*RefOnce, error) {
+	if initFn == nil || teardownFn == nil {
+		return nil, fmt.Errorf("init and teardown functions can't be nil")
+	}
+
+	init, err := NewDefaultOnce(initFn)
+	if err != nil {
+		return nil, err
+	}
+	teardown, err := NewDefaultOnce(teardownFn)
+	if err != nil {
+		return nil, err
+	}
+	return &RefOnce{init: init, teardown: teardown}, nil
+}
+
+// Acquire takes a reference, running the init function if this is the first reference.
+// It returns true if this call ran the init function.
+// Concurrent callers stay blocked till init finishes, so the resource is initialized when Acquire returns.
+// If init panics, the panic is raised in the caller without taking the reference, and the next Acquire runs init again.
+func (r *RefOnce) Acquire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refs++
+	if r.refs == 1 {
+		r.teardown.Reset()
+		defer func() {
+			if p := recover(); p != nil {
+				// init is DONE already since it's a default Once, undo that along with the reference
+				r.refs--
+				r.init.Reset()
+				panic(p)
+			}
+		}()
+		return r.init.Do()
+	}
+	return false
+}
+
+// Release drops a reference, running the teardown function if this was the last reference.
+// It returns true if this call ran the teardown function.
+// Release panics if called more times than Acquire, same as a negative sync.WaitGroup counter.
+func (r *RefOnce) Release() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refs == 0 {
+		panic("sync: negative RefOnce reference count")
+	}
+	r.refs--
+	if r.refs == 0 {
+		r.init.Reset()
+		return r.teardown.Do()
+	}
+	return false
+}
+
+// Refs returns the current number of references.
+func (r *RefOnce) Refs() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.refs
+}
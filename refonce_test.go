@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefOnce(t *testing.T) {
+	_, err := NewRefOnce(returnTrue, nil)
+	assert.NotEqual(t, err, nil)
+
+	inits, teardowns := 0, 0
+	r, err := NewRefOnce(func() bool { inits++; return true }, func() bool { teardowns++; return true })
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, true, r.Acquire())
+	assert.Equal(t, false, r.Acquire())
+	assert.Equal(t, 2, r.Refs())
+	assert.Equal(t, 1, inits)
+
+	assert.Equal(t, false, r.Release())
+	assert.Equal(t, 0, teardowns)
+	assert.Equal(t, true, r.Release())
+	assert.Equal(t, 1, teardowns)
+
+	// re-initialized after the count went to zero
+	assert.Equal(t, true, r.Acquire())
+	assert.Equal(t, 2, inits)
+	assert.Equal(t, true, r.Release())
+	assert.Equal(t, 2, teardowns)
+
+	assert.Panics(t, func() { r.Release() })
+}
+
+func TestRefOnceConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	live := false
+	var events []string
+	record := func(e string, state bool) FuncType {
+		return func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, !state, live)
+			live = state
+			events = append(events, e)
+			return true
+		}
+	}
+	r, err := NewRefOnce(record("init", true), record("teardown", false))
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				r.Acquire()
+				mu.Lock()
+				assert.Equal(t, true, live)
+				mu.Unlock()
+				r.Release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, r.Refs())
+	assert.Equal(t, false, live)
+	// init and teardown strictly alternate
+	for i, e := range events {
+		if i%2 == 0 {
+			assert.Equal(t, "init", e)
+		} else {
+			assert.Equal(t, "teardown", e)
+		}
+	}
+}
+
+func TestRefOnceInitPanic(t *testing.T) {
+	inits, teardowns := 0, 0
+	fail := true
+	r, err := NewRefOnce(func() bool {
+		inits++
+		if fail {
+			panic("boom")
+		}
+		return true
+	}, func() bool { teardowns++; return true })
+	assert.Equal(t, err, nil)
+
+	assert.Panics(t, func() { r.Acquire() })
+	assert.Equal(t, 0, r.Refs())
+	assert.Panics(t, func() { r.Release() })
+	assert.Equal(t, 0, teardowns)
+
+	// init runs again after the panic
+	fail = false
+	assert.Equal(t, true, r.Acquire())
+	assert.Equal(t, 2, inits)
+	assert.Equal(t, true, r.Release())
+	assert.Equal(t, 1, teardowns)
+}
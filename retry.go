@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	MaxAttempts int                             // attempts in total, 0 meaning till ctx is done
+	Backoff     func(attempt int) time.Duration // delay after the failed attempt, starting from 1. nil retries immediately
+	Clock       Clock                           // measures the backoff. Defaults to RealClock
+}
+
+// ExponentialBackoff returns a backoff doubling the delay after each failed attempt, starting from base and capped
+// at max. It can be used for RetryPolicy.Backoff and WithRetry.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Retry calls fn till it returns nil, as per policy, and returns nil once it does. Otherwise it returns the error of
+// the last attempt once MaxAttempts is reached, and that error combined with ctx.Err() if ctx is done first.
+// ctx is passed to fn and is checked before each attempt and during the backoff.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Join(ctxErr, err)
+		}
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+		if policy.Backoff == nil {
+			continue
+		}
+		t := clockOrReal(policy.Clock).NewTimer(policy.Backoff(attempt))
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Join(ctx.Err(), err)
+		}
+	}
+}
+
+// RetryOnce is a Once whose function is retried as per a RetryPolicy till it succeeds, when the RetryOnce becomes
+// DONE. Unlike WithRetry, the retries are bounded by the ctx of each Do(): if it's done first, the RetryOnce stays
+// not DONE and the next Do() starts over. Clients should use NewOnceRetry to create objects.
+type RetryOnce struct {
+	o      *Once
+	policy RetryPolicy
+	f      func(ctx context.Context) error
+}
+
+// NewOnceRetry returns a RetryOnce retrying f as per policy. An error is returned if f is nil or
+// policy.MaxAttempts is negative.
+func NewOnceRetry(policy RetryPolicy, f func(ctx context.Context) error) (*RetryOnce, error) {
+	if f == nil {
+		return nil, fmt.Errorf("function can't be nil")
+	}
+	if policy.MaxAttempts < 0 {
+		return nil, fmt.Errorf("max attempts can't be negative, got %d", policy.MaxAttempts)
+	}
+	// lazyDone = true and VerifyAll, so that the RetryOnce becomes DONE only once f succeeds
+	o, err := NewOnceWithOptions([]FuncType{func() bool { return true }}, WithLazyDone(true), WithVerify(VerifyAll))
+	if err != nil {
+		return nil, err
+	}
+	return &RetryOnce{o: o, policy: policy, f: f}, nil
+}
+
+// Do retries the function till it succeeds, unless the RetryOnce is DONE already, blocking while another goroutine
+// is retrying it. It returns true for the caller whose retries succeeded. If they fail, it returns false with the
+// error of Retry, and a later Do() starts over.
+func (r *RetryOnce) Do(ctx context.Context) (bool, error) {
+	var err error
+	executed := false
+	res := r.o.DoAlso(func() bool {
+		executed = true
+		err = Retry(ctx, r.policy, r.f)
+		return err == nil
+	})
+	if !executed {
+		return false, nil
+	}
+	return res, err
+}
+
+// Done returns whether the function has succeeded. If block = true, it blocks till it has.
+func (r *RetryOnce) Done(block bool) bool {
+	return r.o.Done(block)
+}
+
+// Wait blocks till the function has succeeded, same as Done(true).
+func (r *RetryOnce) Wait() {
+	r.o.Done(true)
+}
+
+// DoneChan returns a channel which is closed once the function has succeeded, to select on it.
+func (r *RetryOnce) DoneChan() <-chan struct{} {
+	return r.o.DoneChan()
+}
+
+// DoneContext blocks till the function has succeeded and returns true, or returns false if ctx is done first.
+func (r *RetryOnce) DoneContext(ctx context.Context) bool {
+	return r.o.DoneContext(ctx)
+}
@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, time.Millisecond, b(1))
+	assert.Equal(t, 2*time.Millisecond, b(2))
+	assert.Equal(t, 4*time.Millisecond, b(3))
+	assert.Equal(t, 5*time.Millisecond, b(4))
+	assert.Equal(t, 5*time.Millisecond, b(100))
+}
+
+func TestRetryFunc(t *testing.T) {
+	errFail := errors.New("fail")
+	calls := 0
+	failTwice := func(context.Context) error {
+		calls++
+		if calls <= 2 {
+			return errFail
+		}
+		return nil
+	}
+	assert.Equal(t, nil, Retry(context.Background(), RetryPolicy{}, failTwice))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	assert.Equal(t, errFail, Retry(context.Background(), RetryPolicy{MaxAttempts: 2}, failTwice))
+	assert.Equal(t, 2, calls)
+
+	// the attempts are spaced by the backoff
+	c := NewManualClock(clockEpoch)
+	policy := RetryPolicy{Backoff: func(int) time.Duration { return time.Hour }, Clock: c}
+	calls = 0
+	done := make(chan error)
+	go func() { done <- Retry(context.Background(), policy, failTwice) }()
+	waitTimers(c, 1)
+	c.Advance(time.Hour - time.Nanosecond)
+	assert.Equal(t, 1, c.Timers())
+	c.Advance(time.Nanosecond)
+	waitTimers(c, 1)
+	c.Advance(time.Hour)
+	assert.Equal(t, nil, <-done)
+	assert.Equal(t, 3, calls)
+
+	// the backoff is interrupted by ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	go func() { done <- Retry(ctx, policy, failTwice) }()
+	waitTimers(c, 1)
+	cancel()
+	err := <-done
+	assert.Equal(t, true, errors.Is(err, context.Canceled))
+	assert.Equal(t, true, errors.Is(err, errFail))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, c.Timers())
+}
+
+func TestNewOnceRetry(t *testing.T) {
+	_, err := NewOnceRetry(RetryPolicy{}, nil)
+	assert.NotEqual(t, err, nil)
+	_, err = NewOnceRetry(RetryPolicy{MaxAttempts: -1}, func(context.Context) error { return nil })
+	assert.NotEqual(t, err, nil)
+}
+
+func TestRetryOnce(t *testing.T) {
+	errFail := errors.New("fail")
+	var calls int32
+	var fail int32 = 3
+	r, err := NewOnceRetry(RetryPolicy{MaxAttempts: 2}, func(context.Context) error {
+		if atomic.AddInt32(&calls, 1) <= atomic.LoadInt32(&fail) {
+			return errFail
+		}
+		return nil
+	})
+	assert.Equal(t, err, nil)
+
+	ok, err := r.Do(context.Background())
+	assert.Equal(t, false, ok)
+	assert.Equal(t, errFail, err)
+	assert.Equal(t, false, r.Done(false))
+
+	// starts over, and succeeds on the second attempt
+	ok, err = r.Do(context.Background())
+	assert.Equal(t, true, ok)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, r.Done(false))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	ok, err = r.Do(context.Background())
+	assert.Equal(t, false, ok)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestRetryOnceConcurrent(t *testing.T) {
+	var calls int32
+	r, _ := NewOnceRetry(RetryPolicy{Backoff: ExponentialBackoff(time.Millisecond, 2*time.Millisecond)},
+		func(context.Context) error {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return errors.New("fail")
+			}
+			return nil
+		})
+	done := make(chan bool)
+	go func() { done <- r.DoneContext(context.Background()) }()
+
+	var wg sync.WaitGroup
+	var winners int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := r.Do(context.Background())
+			assert.Equal(t, nil, err)
+			if ok {
+				atomic.AddInt32(&winners, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, true, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&winners))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
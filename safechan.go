@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SafeChan wraps a channel so that it can be closed while goroutines are sending on it, and closed more than once,
+// without panicking: sends after Close() fail instead, and the channel is closed by a Once. Receivers read from C(),
+// which is closed once all the values sent before Close() are received, same as a plain channel.
+// Clients should use NewSafeChan to create objects.
+type SafeChan[T any] struct {
+	ch   chan T
+	done chan struct{} // closed first by Close(), to unblock the senders
+	mu   sync.RWMutex  // held for reading while sending, so that ch isn't closed during a send
+	o    *Once
+}
+
+// NewSafeChan returns a SafeChan whose channel has a buffer of size buffer. An error is returned if buffer
+// is negative.
+func NewSafeChan[T any](buffer int) (*SafeChan[T], error) {
+	if buffer < 0 {
+		return nil, fmt.Errorf("buffer can't be negative, got %d", buffer)
+	}
+	c := &SafeChan[T]{ch: make(chan T, buffer), done: make(chan struct{})}
+	// lazyDone = true, so the SafeChan becomes DONE only after the channel is closed
+	c.o, _ = NewOnce(true, false, VerifyNone, func() bool {
+		close(c.done)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		close(c.ch)
+		return true
+	})
+	return c, nil
+}
+
+// Send sends v on the channel, blocking till it's received or buffered, or till the SafeChan is closed.
+// It returns whether v was sent.
+func (c *SafeChan[T]) Send(v T) bool {
+	return c.SendCtx(context.Background(), v) == nil
+}
+
+// TrySend sends v on the channel if it can be received or buffered right away, and returns whether it was sent.
+func (c *SafeChan[T]) TrySend(v T) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// SendCtx sends v on the channel, blocking till it's received or buffered, ctx is done or the SafeChan is closed.
+// It returns nil if v was sent, ErrClosed if the SafeChan is closed first and ctx.Err() if ctx is done first.
+func (c *SafeChan[T]) SendCtx(ctx context.Context, v T) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.ch <- v:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// C returns the channel to receive from.
+func (c *SafeChan[T]) C() <-chan T {
+	return c.ch
+}
+
+// Close closes the channel, making the pending and later sends fail. It returns true for the call which closed
+// the channel. Other calls block till the channel is closed and return false.
+func (c *SafeChan[T]) Close() bool {
+	if c.o.Do() {
+		return true
+	}
+	c.o.Done(true)
+	return false
+}
+
+// Closed returns whether the channel is closed. If block = true, it blocks till it is.
+func (c *SafeChan[T]) Closed(block bool) bool {
+	return c.o.Done(block)
+}
@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeChan(t *testing.T) {
+	_, err := NewSafeChan[int](-1)
+	assert.NotEqual(t, err, nil)
+
+	c, err := NewSafeChan[int](1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, c.Send(1))
+	assert.Equal(t, false, c.TrySend(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.SendCtx(ctx, 2))
+
+	assert.Equal(t, false, c.Closed(false))
+	assert.Equal(t, true, c.Close())
+	assert.Equal(t, false, c.Close())
+	assert.Equal(t, true, c.Closed(false))
+
+	assert.Equal(t, false, c.Send(3))
+	assert.Equal(t, false, c.TrySend(3))
+	assert.Equal(t, ErrClosed, c.SendCtx(context.Background(), 3))
+
+	// values sent before Close are still received
+	v, ok := <-c.C()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, v)
+	_, ok = <-c.C()
+	assert.Equal(t, false, ok)
+}
+
+func TestSafeChanCloseUnblocksSenders(t *testing.T) {
+	c, _ := NewSafeChan[int](0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, false, c.Send(i))
+		}()
+	}
+	closed := make(chan bool)
+	go func() { closed <- c.Closed(true) }()
+
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, true, <-closed)
+	_, ok := <-c.C()
+	assert.Equal(t, false, ok)
+}
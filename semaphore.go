@@ -0,0 +1,148 @@
+package sync
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Semaphore is a weighted semaphore: it has a capacity of n units, and each Acquire() takes weight units
+// which are given back by Release(). Blocked callers are served in arrival order, so a large request isn't starved
+// by a stream of small ones. Clients should use NewSemaphore to create objects.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64     // units currently held. guarded by mu
+	waiters list.List // *semaphoreWaiter in arrival order. guarded by mu
+	closed  bool      // guarded by mu
+}
+
+type semaphoreWaiter struct {
+	n        int64
+	ready    chan struct{} // closed when the units are acquired, or the Semaphore is closed
+	acquired bool          // guarded by Semaphore.mu
+}
+
+// NewSemaphore returns a Semaphore with a capacity of n units. An error is returned if n isn't positive.
+func NewSemaphore(n int64) (*Semaphore, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("size needs to be positive, got %d", n)
+	}
+	return &Semaphore{size: n}, nil
+}
+
+// Acquire takes weight units, blocking till they are available, ctx is done or the Semaphore is closed.
+// It returns nil once the units are acquired, ctx.Err() if ctx is done first and ErrClosed if the Semaphore is closed.
+// Nothing is acquired when an error is returned. An error is returned right away for a weight larger than the
+// capacity, as such a request could never be served, and for a negative weight.
+func (s *Semaphore) Acquire(ctx context.Context, weight int64) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if weight < 0 || weight > s.size {
+		s.mu.Unlock()
+		return fmt.Errorf("weight needs to be between 0 and the size %d of the semaphore, got %d", s.size, weight)
+	}
+	if s.size-s.cur >= weight && s.waiters.Len() == 0 {
+		s.cur += weight
+		s.mu.Unlock()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	w := &semaphoreWaiter{n: weight, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !w.acquired {
+			return ErrClosed
+		}
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.acquired {
+			// acquired right when ctx got done, keep the units rather than reshuffling the queue
+			return nil
+		}
+		if !s.closed {
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// the waiters behind this one may fit now
+			if isFront {
+				s.notifyWaiters()
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes weight units if they are available right away, without blocking. It returns whether it did.
+// It returns false if other callers are waiting, so that they keep their place, and if the Semaphore is closed.
+func (s *Semaphore) TryAcquire(weight int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || weight < 0 || s.size-s.cur < weight || s.waiters.Len() > 0 {
+		return false
+	}
+	s.cur += weight
+	return true
+}
+
+// Release gives back weight units, waking up the waiters which fit. Units can be released after Close.
+// Release panics if more units are released than held, same as a negative sync.WaitGroup counter, and for
+// a negative weight. A weight of 0, which Acquire() accepts, is a no-op.
+func (s *Semaphore) Release(weight int64) {
+	if weight < 0 {
+		panic(fmt.Sprintf("sync: semaphore released with negative weight %d", weight))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= weight
+	if s.cur < 0 {
+		s.cur += weight
+		panic("sync: semaphore released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// Close unblocks all goroutines blocked in Acquire() with ErrClosed. Acquire() and TryAcquire() fail
+// after Close, while the units already held can still be released. Close is idempotent.
+func (s *Semaphore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for e := s.waiters.Front(); e != nil; e = e.Next() {
+		close(e.Value.(*semaphoreWaiter).ready)
+	}
+	s.waiters.Init()
+}
+
+// notifyWaiters hands out the available units to the waiters in arrival order, stopping at the first one which
+// doesn't fit. Must be called holding mu.
+func (s *Semaphore) notifyWaiters() {
+	for s.waiters.Len() > 0 {
+		front := s.waiters.Front()
+		w := front.Value.(*semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		w.acquired = true
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
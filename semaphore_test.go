@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSemaphore(t *testing.T) {
+	_, err := NewSemaphore(0)
+	assert.NotEqual(t, err, nil)
+
+	s, err := NewSemaphore(3)
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, nil, s.Acquire(context.Background(), 4))
+	assert.NotEqual(t, nil, s.Acquire(context.Background(), -1))
+	assert.Panics(t, func() { s.Release(1) })
+
+	// a negative weight would push the count past the capacity
+	assert.Equal(t, true, s.TryAcquire(3))
+	assert.PanicsWithValue(t, "sync: semaphore released with negative weight -1", func() { s.Release(-1) })
+	assert.Equal(t, false, s.TryAcquire(1))
+	assert.Equal(t, nil, s.Acquire(context.Background(), 0))
+	s.Release(0)
+	s.Release(3)
+	assert.Equal(t, true, s.TryAcquire(3))
+}
+
+func TestSemaphore(t *testing.T) {
+	s, err := NewSemaphore(3)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, nil, s.Acquire(context.Background(), 2))
+	assert.Equal(t, true, s.TryAcquire(1))
+	assert.Equal(t, false, s.TryAcquire(1))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.Equal(t, nil, s.Acquire(context.Background(), 2))
+		close(acquired)
+	}()
+	time.Sleep(time.Millisecond * 2)
+
+	// one unit isn't enough for the waiter, and TryAcquire doesn't jump the queue
+	s.Release(1)
+	assert.Equal(t, false, s.TryAcquire(1))
+	select {
+	case <-acquired:
+		t.Fatal("acquired without enough units")
+	default:
+	}
+	s.Release(2)
+	<-acquired
+	s.Release(2)
+	assert.Equal(t, true, s.TryAcquire(3))
+}
+
+func TestSemaphoreLimitsConcurrency(t *testing.T) {
+	const n = 3
+	s, err := NewSemaphore(n)
+	assert.Equal(t, err, nil)
+
+	var running, max int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, nil, s.Acquire(context.Background(), 1))
+			defer s.Release(1)
+			r := atomic.AddInt32(&running, 1)
+			for m := atomic.LoadInt32(&max); r > m && !atomic.CompareAndSwapInt32(&max, m, r); m = atomic.LoadInt32(&max) {
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&max) <= n)
+}
+
+func TestSemaphoreContext(t *testing.T) {
+	s, err := NewSemaphore(2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, s.TryAcquire(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Acquire(ctx, 2))
+
+	// a waiter giving up at the front lets the ones behind it through
+	ctx, cancel = context.WithCancel(context.Background())
+	big := make(chan error)
+	go func() { big <- s.Acquire(ctx, 2) }()
+	time.Sleep(time.Millisecond * 2)
+	small := make(chan error)
+	go func() { small <- s.Acquire(context.Background(), 1) }()
+	time.Sleep(time.Millisecond * 2)
+	s.Release(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-big)
+	assert.Equal(t, nil, <-small)
+}
+
+func TestSemaphoreClose(t *testing.T) {
+	s, err := NewSemaphore(1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, s.TryAcquire(1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, ErrClosed, s.Acquire(context.Background(), 1))
+		}()
+	}
+	time.Sleep(time.Millisecond * 2)
+	s.Close()
+	s.Close()
+	wg.Wait()
+
+	assert.Equal(t, ErrClosed, s.Acquire(context.Background(), 1))
+	assert.Equal(t, false, s.TryAcquire(0))
+	assert.NotPanics(t, func() { s.Release(1) })
+}
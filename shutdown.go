@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Shutdown coordinates the teardown of an application: components register cleanup hooks as they start, and
+// Shutdown() runs the hooks exactly once, in reverse registration order so that components are torn down before
+// the ones they depend on. The run is enforced to happen once by a Once: concurrent and later calls wait for it
+// and get the same result. Clients should use NewShutdown to create objects.
+type Shutdown struct {
+	o *Once
+
+	mu      sync.Mutex
+	hooks   []shutdownHook // guarded by mu
+	started bool           // guarded by mu
+	err     error          // written by the run, read once the Once is DONE
+}
+
+type shutdownHook struct {
+	priority int
+	fn       func(ctx context.Context) error
+}
+
+// NewShutdown returns a Shutdown with no hooks.
+func NewShutdown() *Shutdown {
+	// lazyDone = true, so the Shutdown becomes DONE only after the result is stored
+	o, _ := NewOnce(true, false, VerifyNone, func() bool { return true })
+	return &Shutdown{o: o}
+}
+
+// Register adds a hook with priority 0. See RegisterPriority.
+func (s *Shutdown) Register(fn func(ctx context.Context) error) error {
+	return s.RegisterPriority(0, fn)
+}
+
+// RegisterPriority adds a hook to be run by Shutdown(). Hooks with a higher priority run first, and hooks with the
+// same priority run in reverse registration order. It returns ErrClosed if Shutdown() has started already, in which
+// case the hook is never run.
+func (s *Shutdown) RegisterPriority(priority int, fn func(ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrClosed
+	}
+	s.hooks = append(s.hooks, shutdownHook{priority: priority, fn: fn})
+	return nil
+}
+
+// Shutdown runs the hooks one at a time, passing them ctx, and returns their errors combined with errors.Join.
+// A panic in a hook is recovered and reported as a *PanicError, and the next hooks still run. If ctx is done
+// before all the hooks have returned, the remaining hooks are skipped, the hook running is left running in the
+// background, and ctx.Err() is part of the result.
+// Only the first call runs the hooks. Other calls wait for it and return the same result, or ctx.Err() if their
+// ctx is done first.
+func (s *Shutdown) Shutdown(ctx context.Context) error {
+	// the others don't call DoAlso, which would block them till the run is over whatever their ctx
+	if s.start() {
+		s.o.DoAlso(func() bool {
+			s.err = s.run(ctx)
+			return true
+		})
+	}
+	if !s.o.DoneContext(ctx) {
+		return ctx.Err()
+	}
+	return s.err
+}
+
+// Done returns whether Shutdown() has completed. If block = true, it blocks till it has.
+func (s *Shutdown) Done(block bool) bool {
+	return s.o.Done(block)
+}
+
+// Wait blocks till Shutdown() has completed, same as Done(true).
+func (s *Shutdown) Wait() {
+	s.o.Done(true)
+}
+
+// DoneChan returns a channel which is closed once Shutdown() has completed, to select on it.
+func (s *Shutdown) DoneChan() <-chan struct{} {
+	return s.o.DoneChan()
+}
+
+// start marks the Shutdown as started and returns whether it wasn't already, i.e. the caller is to run the hooks.
+func (s *Shutdown) start() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := !s.started
+	s.started = true
+	return first
+}
+
+func (s *Shutdown) run(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := make([]shutdownHook, len(s.hooks))
+	for i, h := range s.hooks {
+		hooks[len(hooks)-1-i] = h
+	}
+	s.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].priority > hooks[j].priority })
+
+	var errs []error
+	for _, h := range hooks {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		done := make(chan error, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- newPanicError(p)
+				}
+			}()
+			done <- h.fn(ctx)
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+	return errors.Join(errs...)
+}
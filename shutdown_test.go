@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	s := NewShutdown()
+	var order []int
+	hook := func(i int, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, i)
+			return err
+		}
+	}
+	errHook := errors.New("hook failed")
+	assert.Equal(t, nil, s.Register(hook(1, nil)))
+	assert.Equal(t, nil, s.Register(hook(2, errHook)))
+	assert.Equal(t, nil, s.RegisterPriority(10, hook(3, nil)))
+	assert.Equal(t, nil, s.Register(func(context.Context) error { panic("boom") }))
+	assert.Equal(t, false, s.Done(false))
+
+	err := s.Shutdown(context.Background())
+	assert.Equal(t, true, errors.Is(err, errHook))
+	var pe *PanicError
+	assert.Equal(t, true, errors.As(err, &pe))
+	assert.Equal(t, []int{3, 2, 1}, order)
+	assert.Equal(t, true, s.Done(false))
+
+	// later calls return the same result without running the hooks again
+	assert.Equal(t, err, s.Shutdown(context.Background()))
+	assert.Equal(t, []int{3, 2, 1}, order)
+	assert.Equal(t, ErrClosed, s.Register(hook(4, nil)))
+}
+
+func TestShutdownConcurrent(t *testing.T) {
+	s := NewShutdown()
+	runs := 0
+	s.Register(func(context.Context) error {
+		runs++
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, nil, s.Shutdown(context.Background()))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, runs)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	s := NewShutdown()
+	ran := false
+	s.Register(func(context.Context) error {
+		ran = true
+		return nil
+	})
+	release := make(chan struct{})
+	defer close(release)
+	s.Register(func(context.Context) error {
+		// ignores ctx
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	assert.Equal(t, true, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, false, ran)
+	assert.Equal(t, err, s.Shutdown(context.Background()))
+}
+
+func TestShutdownOtherCallerDeadline(t *testing.T) {
+	s := NewShutdown()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s.Register(func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	first := make(chan error)
+	go func() { first <- s.Shutdown(context.Background()) }()
+	<-started
+
+	// the hook being run doesn't hold back a caller whose ctx expires
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Shutdown(ctx))
+	assert.Equal(t, false, s.Done(false))
+
+	close(release)
+	assert.Equal(t, nil, <-first)
+	assert.Equal(t, nil, s.Shutdown(context.Background()))
+}
@@ -0,0 +1,109 @@
+package sync
+
+import "sync"
+
+// SingleFlight suppresses duplicate calls: while a function is executing for a key, other callers for the same key
+// wait for it and share its result instead of executing their own. Unlike a KeyedOnce, the key is forgotten once
+// the function returns, so the next call executes again. It's a Once per concurrent burst of calls.
+// A panic in the function is recovered and returned to all the callers as a *PanicError.
+// The zero value is ready to use. A SingleFlight must not be copied after first use.
+type SingleFlight[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]*flightCall[V] // calls in progress. guarded by mu
+}
+
+// SingleFlightResult is the result of a call delivered by SingleFlight.DoChan.
+type SingleFlightResult[V any] struct {
+	Val    V
+	Err    error
+	Shared bool // the result was given to more than one caller
+}
+
+type flightCall[V any] struct {
+	done  chan struct{} // closed once val and err are set
+	val   V
+	err   error
+	dups  int                          // number of callers which joined the call. guarded by SingleFlight.mu
+	chans []chan SingleFlightResult[V] // guarded by SingleFlight.mu
+}
+
+// Do executes fn for key and returns its result, unless a call for key is in progress, in which case it waits
+// for that call and returns its result. shared tells whether the result was given to more than one caller.
+func (g *SingleFlight[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	g.mu.Lock()
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+	c := g.start(key)
+	g.mu.Unlock()
+
+	shared = g.call(c, key, fn)
+	return c.val, c.err, shared
+}
+
+// DoChan is same as Do() but doesn't block. The result is delivered on the returned channel, which has a buffer
+// of one. The function is executed in a new goroutine if no call for key is in progress.
+func (g *SingleFlight[K, V]) DoChan(key K, fn func() (V, error)) <-chan SingleFlightResult[V] {
+	ch := make(chan SingleFlightResult[V], 1)
+	g.mu.Lock()
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+		return ch
+	}
+	c := g.start(key)
+	c.chans = append(c.chans, ch)
+	g.mu.Unlock()
+
+	go g.call(c, key, fn)
+	return ch
+}
+
+// Forget makes the next call for key execute its function, even if a call for key is in progress.
+// The callers already waiting for that call still get its result.
+func (g *SingleFlight[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.m, key)
+}
+
+// start registers a new call for key. Must be called holding mu.
+func (g *SingleFlight[K, V]) start(key K) *flightCall[V] {
+	c := &flightCall[V]{done: make(chan struct{})}
+	if g.m == nil {
+		g.m = make(map[K]*flightCall[V])
+	}
+	g.m[key] = c
+	return c
+}
+
+// call executes fn for c and delivers the result to the callers waiting for it. It returns whether the result
+// was shared.
+func (g *SingleFlight[K, V]) call(c *flightCall[V], key K, fn func() (V, error)) bool {
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				c.err = newPanicError(p)
+			}
+		}()
+		c.val, c.err = fn()
+	}()
+
+	g.mu.Lock()
+	if g.m[key] == c {
+		delete(g.m, key)
+	}
+	shared := c.dups > 0
+	chans := c.chans
+	g.mu.Unlock()
+
+	close(c.done)
+	for _, ch := range chans {
+		ch <- SingleFlightResult[V]{c.val, c.err, shared}
+	}
+	return shared
+}
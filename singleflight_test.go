@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleFlight(t *testing.T) {
+	var g SingleFlight[string, int]
+	v, err, shared := g.Do("a", func() (int, error) { return 1, nil })
+	assert.Equal(t, 1, v)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, shared)
+
+	// the key is forgotten once the call returns
+	errFail := errors.New("fail")
+	v, err, _ = g.Do("a", func() (int, error) { return 2, errFail })
+	assert.Equal(t, 2, v)
+	assert.Equal(t, errFail, err)
+}
+
+func TestSingleFlightDuplicates(t *testing.T) {
+	const n = 10
+	var g SingleFlight[string, int]
+	var calls, sharedCount int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			v, err, shared := g.Do("key", fn)
+			assert.Equal(t, 42, v)
+			assert.Equal(t, nil, err)
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+		}()
+	}
+	for {
+		g.mu.Lock()
+		c := g.m["key"]
+		joined := c != nil && c.dups == n-1
+		g.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, int32(n), sharedCount)
+}
+
+func TestSingleFlightDoChanAndForget(t *testing.T) {
+	var g SingleFlight[int, string]
+	release := make(chan struct{})
+	ch1 := g.DoChan(1, func() (string, error) { <-release; return "first", nil })
+	ch2 := g.DoChan(1, func() (string, error) { return "joined", nil })
+
+	// after Forget a new call executes on its own
+	g.Forget(1)
+	v, _, shared := g.Do(1, func() (string, error) { return "second", nil })
+	assert.Equal(t, "second", v)
+	assert.Equal(t, false, shared)
+
+	close(release)
+	r1, r2 := <-ch1, <-ch2
+	assert.Equal(t, SingleFlightResult[string]{Val: "first", Shared: true}, r1)
+	assert.Equal(t, r1, r2)
+}
+
+func TestSingleFlightPanic(t *testing.T) {
+	var g SingleFlight[string, int]
+	_, err, _ := g.Do("a", func() (int, error) { panic("boom") })
+	assert.True(t, errors.Is(err, ErrPanicked))
+
+	r := <-g.DoChan("a", func() (int, error) { panic("boom") })
+	assert.True(t, errors.Is(r.Err, ErrPanicked))
+}
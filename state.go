@@ -0,0 +1,53 @@
+package sync
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// State is the state of a Once as reported by Once.State.
+type State int
+
+const (
+	StateNotStarted State = iota // the function/s haven't been executed in the current generation
+	StateRunning                 // the function/s are executing
+	StateDone                    // the latest execution succeeded
+	StateFailed                  // the latest execution failed i.e. it panicked, returned false as per verify or recorded an error
+	StateClosed                  // the Once was closed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateNotStarted:
+		return "NotStarted"
+	case StateRunning:
+		return "Running"
+	case StateDone:
+		return "Done"
+	case StateFailed:
+		return "Failed"
+	case StateClosed:
+		return "Closed"
+	default:
+		return "State(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// State returns the state of the Once, e.g. for monitoring the progress of an initialization. It never blocks.
+// Running takes precedence: a Once closed while the function/s execute is reported as running till they return.
+// After a failed execution the state stays failed, even if DONE was set because lazyDone = false,
+// till the next execution or Reset().
+func (d *Once) State() State {
+	switch {
+	case atomic.LoadUint32(&d.running) == 1:
+		return StateRunning
+	case atomic.LoadUint32(&d.unblock) == 1:
+		return StateClosed
+	case atomic.LoadUint32(&d.failed) == 1:
+		return StateFailed
+	case atomic.LoadUint32(&d.done) == 1:
+		return StateDone
+	default:
+		return StateNotStarted
+	}
+}
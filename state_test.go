@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	var (
+		err error
+		o   *Once
+	)
+
+	release := make(chan struct{})
+	o, err = NewDefaultOnce(func() bool {
+		<-release
+		return true
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, StateNotStarted, o.State())
+	go o.Do()
+	o.WaitReady()
+	assert.Equal(t, StateRunning, o.State())
+	close(release)
+	o.CloseGraceful(context.Background())
+	assert.Equal(t, StateClosed, o.State())
+	o.Reset()
+	assert.Equal(t, StateNotStarted, o.State())
+	o.Do()
+	assert.Equal(t, StateDone, o.State())
+
+	// a panic is a failure even if DONE was set before executing
+	o, err = NewOnce(false, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, true, o.Done(false))
+	assert.Equal(t, StateFailed, o.State())
+	o.Reset()
+	assert.Equal(t, StateNotStarted, o.State())
+
+	// failed verification, followed by a successful execution
+	ok := false
+	o, err = NewOnce(true, false, VerifyAll, func() bool { return ok })
+	assert.Equal(t, err, nil)
+	o.Do()
+	assert.Equal(t, StateFailed, o.State())
+	ok = true
+	o.Do()
+	assert.Equal(t, StateDone, o.State())
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "NotStarted", StateNotStarted.String())
+	assert.Equal(t, "Running", StateRunning.String())
+	assert.Equal(t, "Done", StateDone.String())
+	assert.Equal(t, "Failed", StateFailed.String())
+	assert.Equal(t, "Closed", StateClosed.String())
+	assert.Equal(t, "State(9)", State(9).String())
+}
@@ -0,0 +1,30 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the counters of a Once, e.g. to debug slow startup paths. See Once.Stats.
+// The counters are since the creation of the Once and are not affected by Reset().
+type Stats struct {
+	Calls      uint64        // calls to Do() and its variants
+	Blocked    uint64        // calls to Do() and its variants which had to wait for the Once held by another goroutine
+	Executions uint64        // executions of the function/s, same as Count()
+	Released   uint64        // goroutines released after blocking in Done(true), WaitReady() and the like
+	Waiters    int           // goroutines blocked right now, same as Waiters()
+	Duration   time.Duration // duration of the latest execution in the current generation, same as Duration()
+}
+
+// Stats returns the counters of the Once. It never blocks. The counters are read one at a time,
+// so while the Once is in use they may not be consistent with each other.
+func (d *Once) Stats() Stats {
+	return Stats{
+		Calls:      atomic.LoadUint64(&d.calls),
+		Blocked:    atomic.LoadUint64(&d.blocked),
+		Executions: d.Count(),
+		Released:   atomic.LoadUint64(&d.released),
+		Waiters:    d.Waiters(),
+		Duration:   d.Duration(),
+	}
+}
@@ -0,0 +1,72 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	const n = 4
+	var wg sync.WaitGroup
+
+	release := make(chan struct{})
+	o, err := NewOnce(true, false, VerifyNone, func() bool {
+		<-release
+		time.Sleep(time.Millisecond)
+		return true
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, Stats{}, o.Stats())
+
+	go o.Do()
+	for o.State() != StateRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	// n waiters on Done(true) and n callers of Do() and DoOrWait() blocked behind the execution
+	wg.Add(2 * n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			o.Done(true)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				assert.Equal(t, false, o.Do())
+			} else {
+				// blocks once in Do(), then takes mu again uncounted
+				assert.Equal(t, false, o.DoOrWait())
+			}
+		}(i)
+	}
+	waitForWaiters(t, o, n)
+	for i := 0; o.Stats().Blocked != n; i++ {
+		if i == 1000 {
+			t.Fatalf("expected %d blocked calls, got %d", n, o.Stats().Blocked)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, n, o.Stats().Waiters)
+	close(release)
+	wg.Wait()
+
+	// calls after DONE take the fast path
+	o.Do()
+	o.TryDo()
+	s := o.Stats()
+	assert.Equal(t, uint64(1+n+2), s.Calls)
+	assert.Equal(t, uint64(n), s.Blocked)
+	assert.Equal(t, uint64(1), s.Executions)
+	assert.Equal(t, uint64(n), s.Released)
+	assert.Equal(t, 0, s.Waiters)
+	assert.True(t, s.Duration >= time.Millisecond)
+
+	// a call through DoContext is counted once
+	_, err = o.DoContext(t.Context())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.Calls+1, o.Stats().Calls)
+}
@@ -1,6 +1,6 @@
 // This package provides some features available in golang's sync package with some enhancements.
 //
-// Once
+// # Once
 //
 // The Once defined by this package is a stateful implementation which can be passed around to other fuctions.
 // It adds additional features to test whether the operations have already been Done and also allows Reset.
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClosed is returned when a result can't be produced because the Once was closed before the function/s ran.
+// The other primitives of this package which can be closed, e.g. Semaphore, return it as well.
+var ErrClosed = errors.New("closed")
+
+// TypedOnce is a Once which caches the value and error returned by its function and serves them to all callers.
+// Clients should use NewTypedOnce to create objects. A TypedOnce must not be copied after first use.
+type TypedOnce[T any] struct {
+	noCopy noCopy
+	o      *Once
+	value  T
+	err    error
+}
+
+// NewTypedOnce returns a TypedOnce for f.
+// The state becomes DONE only after f returns, so all callers of Do() get the value computed by f.
+// If f panics, the panic is raised in the caller of Do() and the state doesn't become DONE.
+func NewTypedOnce[T any](f func() (T, error)) (*TypedOnce[T], error) {
+	if f == nil {
+		return nil, fmt.Errorf("function at index 0 is nil")
+	}
+
+	t := &TypedOnce[T]{}
+	o, err := NewOnce(true, false, VerifyNone, func() bool {
+		t.value, t.err = f()
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.o = o
+	return t, nil
+}
+
+// Do calls f if it hasn't been called yet and returns the cached value and error.
+// Concurrent callers stay blocked till f returns.
+// If the TypedOnce was closed before f could run, the zero value and ErrClosed are returned.
+func (t *TypedOnce[T]) Do() (T, error) {
+	t.o.Do()
+	if v, ok := t.Value(); ok {
+		return v, t.err
+	}
+	var zero T
+	return zero, ErrClosed
+}
+
+// Done is same as Once.Done.
+func (t *TypedOnce[T]) Done(block bool) bool {
+	return t.o.Done(block)
+}
+
+// Value returns the value computed by f and true. It returns the zero value and false if f hasn't completed yet.
+// Value never blocks.
+func (t *TypedOnce[T]) Value() (T, bool) {
+	if !t.o.Done(false) {
+		var zero T
+		return zero, false
+	}
+	return t.value, true
+}
+
+// Err returns the error returned by f. It is nil till f has completed.
+func (t *TypedOnce[T]) Err() error {
+	if !t.o.Done(false) {
+		return nil
+	}
+	return t.err
+}
+
+// Close is same as Once.Close.
+func (t *TypedOnce[T]) Close() {
+	t.o.Close()
+}
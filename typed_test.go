@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedOnce(t *testing.T) {
+	_, err := NewTypedOnce[int](nil)
+	assert.NotEqual(t, err, nil)
+
+	calls := 0
+	o, err := NewTypedOnce(func() (int, error) { calls++; return 42, nil })
+	assert.Equal(t, err, nil)
+
+	v, ok := o.Value()
+	assert.Equal(t, 0, v)
+	assert.Equal(t, false, ok)
+	assert.Equal(t, false, o.Done(false))
+
+	v, err = o.Do()
+	assert.Equal(t, 42, v)
+	assert.Equal(t, err, nil)
+	v, err = o.Do()
+	assert.Equal(t, 42, v)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 1, calls)
+
+	v, ok = o.Value()
+	assert.Equal(t, 42, v)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestTypedOnceError(t *testing.T) {
+	e := errors.New("failed")
+	o, err := NewTypedOnce(func() (string, error) { return "", e })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, o.Err(), nil)
+
+	_, err = o.Do()
+	assert.Equal(t, e, err)
+	assert.Equal(t, e, o.Err())
+	assert.Equal(t, true, o.Done(false))
+}
+
+func TestTypedOnceClose(t *testing.T) {
+	o, err := NewTypedOnce(func() (int, error) { return 1, nil })
+	assert.Equal(t, err, nil)
+	o.Close()
+	assert.Equal(t, false, o.Done(true))
+	v, err := o.Do()
+	assert.Equal(t, 0, v)
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestTypedOnceConcurrent(t *testing.T) {
+	var calls int32
+	o, err := NewTypedOnce(func() ([]int, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond * 2)
+		return []int{1, 2}, nil
+	})
+	assert.Equal(t, err, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			v, err := o.Do()
+			assert.Equal(t, []int{1, 2}, v)
+			assert.Equal(t, err, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, true, o.Done(true))
+			v, ok := o.Value()
+			assert.Equal(t, []int{1, 2}, v)
+			assert.Equal(t, true, ok)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+}
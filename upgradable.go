@@ -0,0 +1,232 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// UpgradableRWMutex is a reader/writer mutual exclusion lock which also has an upgradable read lock: it's shared with
+// the plain readers, but can be upgraded to the write lock without releasing it, e.g. for check-then-update critical
+// sections. Only one goroutine can hold the upgradable read lock at a time, so two upgrades can never wait for each
+// other. Same as with RWMutex, a blocked writer or upgrade keeps new readers out. Its zero value is an unlocked mutex.
+// An UpgradableRWMutex must not be copied after first use.
+type UpgradableRWMutex struct {
+	mu             sync.Mutex
+	readers        int           // plain readers. guarded by mu
+	writer         bool          // guarded by mu
+	upgradable     bool          // the upgradable read lock is held. guarded by mu
+	upgrading      bool          // the holder of the upgradable read lock waits for the readers to leave. guarded by mu
+	writersWaiting int           // guarded by mu
+	wakeCh         chan struct{} // closed to wake up the blocked goroutines on every release. guarded by mu
+}
+
+// Lock locks rw for writing, blocking till no reader, upgradable reader or writer holds it.
+func (rw *UpgradableRWMutex) Lock() {
+	rw.LockCtx(context.Background())
+}
+
+// TryLock locks rw for writing if it's available right away and returns whether it did.
+func (rw *UpgradableRWMutex) TryLock() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.writer || rw.upgradable || rw.readers > 0 {
+		return false
+	}
+	rw.writer = true
+	return true
+}
+
+// LockCtx is same as Lock() but gives up when ctx is done. It returns nil if rw was locked, else ctx.Err().
+func (rw *UpgradableRWMutex) LockCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	waiting := false
+	for rw.writer || rw.upgradable || rw.readers > 0 {
+		if !waiting {
+			// keeps new readers out till this writer is done
+			rw.writersWaiting++
+			waiting = true
+		}
+		if err := rw.wait(ctx); err != nil {
+			rw.writersWaiting--
+			// readers held back by this writer may go ahead now
+			rw.wakeAll()
+			return err
+		}
+	}
+	if waiting {
+		rw.writersWaiting--
+	}
+	rw.writer = true
+	return nil
+}
+
+// Unlock unlocks rw for writing, including after Upgrade(). It panics if rw isn't locked for writing.
+func (rw *UpgradableRWMutex) Unlock() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if !rw.writer {
+		panic("sync: Unlock of unlocked UpgradableRWMutex")
+	}
+	rw.writer = false
+	rw.wakeAll()
+}
+
+// RLock locks rw for reading, blocking while a writer holds it or is waiting for it.
+func (rw *UpgradableRWMutex) RLock() {
+	rw.RLockCtx(context.Background())
+}
+
+// TryRLock locks rw for reading if it's available right away and returns whether it did.
+func (rw *UpgradableRWMutex) TryRLock() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.writer || rw.writersWaiting > 0 || rw.upgrading {
+		return false
+	}
+	rw.readers++
+	return true
+}
+
+// RLockCtx is same as RLock() but gives up when ctx is done. It returns nil if rw was locked, else ctx.Err().
+func (rw *UpgradableRWMutex) RLockCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for rw.writer || rw.writersWaiting > 0 || rw.upgrading {
+		if err := rw.wait(ctx); err != nil {
+			return err
+		}
+	}
+	rw.readers++
+	return nil
+}
+
+// RUnlock undoes a single RLock() call. It panics if rw isn't locked for reading.
+func (rw *UpgradableRWMutex) RUnlock() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.readers == 0 {
+		panic("sync: RUnlock of unlocked UpgradableRWMutex")
+	}
+	rw.readers--
+	if rw.readers == 0 {
+		rw.wakeAll()
+	}
+}
+
+// UpgradableRLock locks rw for reading with the option to upgrade, blocking while a writer or another upgradable
+// reader holds it, or a writer is waiting for it. Release it with UpgradableRUnlock(), or with Unlock() after
+// Upgrade().
+func (rw *UpgradableRWMutex) UpgradableRLock() {
+	rw.UpgradableRLockCtx(context.Background())
+}
+
+// TryUpgradableRLock is same as UpgradableRLock() but doesn't block. It returns whether rw was locked.
+func (rw *UpgradableRWMutex) TryUpgradableRLock() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.writer || rw.upgradable || rw.writersWaiting > 0 {
+		return false
+	}
+	rw.upgradable = true
+	return true
+}
+
+// UpgradableRLockCtx is same as UpgradableRLock() but gives up when ctx is done. It returns nil if rw was locked,
+// else ctx.Err().
+func (rw *UpgradableRWMutex) UpgradableRLockCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for rw.writer || rw.upgradable || rw.writersWaiting > 0 {
+		if err := rw.wait(ctx); err != nil {
+			return err
+		}
+	}
+	rw.upgradable = true
+	return nil
+}
+
+// UpgradableRUnlock releases the upgradable read lock. It panics if it isn't held.
+func (rw *UpgradableRWMutex) UpgradableRUnlock() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if !rw.upgradable {
+		panic("sync: UpgradableRUnlock of unlocked UpgradableRWMutex")
+	}
+	rw.upgradable = false
+	rw.wakeAll()
+}
+
+// Upgrade turns the upgradable read lock held by the caller into the write lock, blocking till the plain readers
+// leave. New readers are kept out meanwhile. It panics if the upgradable read lock isn't held.
+func (rw *UpgradableRWMutex) Upgrade() {
+	rw.UpgradeCtx(context.Background())
+}
+
+// TryUpgrade turns the upgradable read lock held by the caller into the write lock if there are no plain readers,
+// and returns whether it did. It panics if the upgradable read lock isn't held.
+func (rw *UpgradableRWMutex) TryUpgrade() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.checkUpgradable()
+	if rw.readers > 0 {
+		return false
+	}
+	rw.upgradable, rw.writer = false, true
+	return true
+}
+
+// UpgradeCtx is same as Upgrade() but gives up when ctx is done. It returns nil if the lock was upgraded, else
+// ctx.Err(), in which case the caller still holds the upgradable read lock.
+func (rw *UpgradableRWMutex) UpgradeCtx(ctx context.Context) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.checkUpgradable()
+	rw.upgrading = true
+	for rw.readers > 0 {
+		if err := rw.wait(ctx); err != nil {
+			rw.upgrading = false
+			// readers held back by the upgrade may go ahead now
+			rw.wakeAll()
+			return err
+		}
+	}
+	rw.upgrading = false
+	rw.upgradable, rw.writer = false, true
+	return nil
+}
+
+// checkUpgradable panics if the upgradable read lock isn't held. Must be called holding mu.
+func (rw *UpgradableRWMutex) checkUpgradable() {
+	if !rw.upgradable {
+		panic("sync: Upgrade of UpgradableRWMutex without the upgradable read lock")
+	}
+}
+
+// wait releases mu till the next release of rw or ctx is done, in which case it returns ctx.Err().
+// Must be called holding mu, which is held again when it returns.
+func (rw *UpgradableRWMutex) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rw.wakeCh == nil {
+		rw.wakeCh = make(chan struct{})
+	}
+	ch := rw.wakeCh
+	rw.mu.Unlock()
+	defer rw.mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wakeAll wakes up the goroutines blocked in wait(). Must be called holding mu.
+func (rw *UpgradableRWMutex) wakeAll() {
+	if rw.wakeCh != nil {
+		close(rw.wakeCh)
+		rw.wakeCh = nil
+	}
+}
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradableRWMutex(t *testing.T) {
+	var rw UpgradableRWMutex
+	assert.Panics(t, func() { rw.Upgrade() })
+	assert.Panics(t, func() { rw.UpgradableRUnlock() })
+
+	// shared with the plain readers, exclusive with writers and other upgradable readers
+	rw.UpgradableRLock()
+	assert.Equal(t, true, rw.TryRLock())
+	assert.Equal(t, false, rw.TryLock())
+	assert.Equal(t, false, rw.TryUpgradableRLock())
+
+	assert.Equal(t, false, rw.TryUpgrade())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, rw.UpgradeCtx(ctx))
+	// still held after the failed upgrade, and readers aren't kept out anymore
+	assert.Equal(t, true, rw.TryRLock())
+	rw.RUnlock()
+	rw.RUnlock()
+
+	assert.Equal(t, true, rw.TryUpgrade())
+	assert.Equal(t, false, rw.TryRLock())
+	rw.Unlock()
+
+	assert.Equal(t, true, rw.TryUpgradableRLock())
+	rw.UpgradableRUnlock()
+	assert.Equal(t, true, rw.TryLock())
+	rw.Unlock()
+}
+
+func TestUpgradableRWMutexUpgradeWaitsForReaders(t *testing.T) {
+	var rw UpgradableRWMutex
+	rw.RLock()
+	rw.UpgradableRLock()
+
+	upgraded := make(chan struct{})
+	go func() {
+		rw.Upgrade()
+		close(upgraded)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	select {
+	case <-upgraded:
+		t.Fatal("upgraded while a reader holds the lock")
+	default:
+	}
+	// new readers are kept out by the pending upgrade
+	assert.Equal(t, false, rw.TryRLock())
+
+	rw.RUnlock()
+	<-upgraded
+	rw.Unlock()
+	assert.Equal(t, true, rw.TryRLock())
+	rw.RUnlock()
+}
+
+func TestUpgradableRWMutexConcurrent(t *testing.T) {
+	var rw UpgradableRWMutex
+	m := map[int]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rw.UpgradableRLock()
+				if _, ok := m[j]; ok {
+					rw.UpgradableRUnlock()
+					continue
+				}
+				rw.Upgrade()
+				m[j] = i
+				rw.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rw.RLock()
+				_ = m[j]
+				rw.RUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, len(m))
+}
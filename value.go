@@ -0,0 +1,74 @@
+package sync
+
+import "sync/atomic"
+
+// Value is a type safe replacement of the Value of golang's sync/atomic package. Unlike atomic.Value, it accepts
+// nil values and values of different concrete types when T is an interface. Each Store keeps the value in a new
+// box, which is swapped in atomically, so loads never block. The zero value holds the zero value of T.
+// A Value must not be copied after first use.
+type Value[T any] struct {
+	p atomic.Pointer[valueBox[T]]
+}
+
+type valueBox[T any] struct {
+	v T
+}
+
+// Load returns the value.
+func (v *Value[T]) Load() T {
+	if b := v.p.Load(); b != nil {
+		return b.v
+	}
+	var zero T
+	return zero
+}
+
+// Store sets the value to val.
+func (v *Value[T]) Store(val T) {
+	v.p.Store(&valueBox[T]{val})
+}
+
+// Swap sets the value to val and returns the previous value.
+func (v *Value[T]) Swap(val T) T {
+	if b := v.p.Swap(&valueBox[T]{val}); b != nil {
+		return b.v
+	}
+	var zero T
+	return zero
+}
+
+// CompareAndSwap sets the value to new if it's equal to old, and returns whether it did. Same as with
+// atomic.Value, the values are compared with ==, which panics if T isn't comparable at run time.
+func (v *Value[T]) CompareAndSwap(old, new T) bool {
+	nb := &valueBox[T]{new}
+	for {
+		b := v.p.Load()
+		var cur T
+		if b != nil {
+			cur = b.v
+		}
+		if any(cur) != any(old) {
+			return false
+		}
+		if v.p.CompareAndSwap(b, nb) {
+			return true
+		}
+	}
+}
+
+// Update sets the value to f applied to the current value, retrying with the new current value if another
+// goroutine changes it meanwhile, and returns the value set. f may be called more than once so it should be free
+// of side effects. T doesn't need to be comparable.
+func (v *Value[T]) Update(f func(T) T) T {
+	for {
+		b := v.p.Load()
+		var cur T
+		if b != nil {
+			cur = b.v
+		}
+		nb := &valueBox[T]{f(cur)}
+		if v.p.CompareAndSwap(b, nb) {
+			return nb.v
+		}
+	}
+}
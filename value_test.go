@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue(t *testing.T) {
+	var v Value[int]
+	assert.Equal(t, 0, v.Load())
+	assert.Equal(t, true, v.CompareAndSwap(0, 1))
+	assert.Equal(t, false, v.CompareAndSwap(0, 2))
+	assert.Equal(t, 1, v.Load())
+
+	v.Store(3)
+	assert.Equal(t, 3, v.Swap(4))
+	assert.Equal(t, 4, v.Load())
+	assert.Equal(t, 5, v.Update(func(i int) int { return i + 1 }))
+}
+
+func TestValueInterface(t *testing.T) {
+	// nil and different concrete types are fine, unlike with atomic.Value
+	var v Value[error]
+	assert.Equal(t, nil, v.Load())
+	v.Store(io.EOF)
+	v.Store(errors.New("other"))
+	v.Store(nil)
+	assert.Equal(t, nil, v.Load())
+	assert.Equal(t, true, v.CompareAndSwap(nil, io.EOF))
+	assert.Equal(t, io.EOF, v.Load())
+
+	var s Value[[]int]
+	assert.Panics(t, func() { s.CompareAndSwap(nil, []int{1}) })
+	assert.Equal(t, []int{1}, s.Update(func(cur []int) []int { return append(cur, 1) }))
+}
+
+func TestValueUpdateConcurrent(t *testing.T) {
+	var v Value[int]
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				v.Update(func(i int) int { return i + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 800, v.Load())
+}
@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// VersionedValue holds a value along with a version incremented by each Set(), so that readers can wait till the
+// value they depend on is visible, e.g. for read-your-writes after a config update: the writer passes the version
+// returned by Set() to the reader, which calls WaitVersion(). Clients should use NewVersionedValue to create objects.
+type VersionedValue[T any] struct {
+	mu      sync.Mutex
+	value   T             // guarded by mu
+	version uint64        // guarded by mu
+	changed chan struct{} // closed by the next Set, then replaced. guarded by mu
+}
+
+// NewVersionedValue returns a VersionedValue holding v at version 0.
+func NewVersionedValue[T any](v T) *VersionedValue[T] {
+	return &VersionedValue[T]{value: v, changed: make(chan struct{})}
+}
+
+// Get returns the value and its version.
+func (vv *VersionedValue[T]) Get() (T, uint64) {
+	vv.mu.Lock()
+	defer vv.mu.Unlock()
+	return vv.value, vv.version
+}
+
+// Set sets the value to v and returns its version, releasing the readers waiting for it.
+func (vv *VersionedValue[T]) Set(v T) uint64 {
+	vv.mu.Lock()
+	defer vv.mu.Unlock()
+	vv.value = v
+	vv.version++
+	close(vv.changed)
+	vv.changed = make(chan struct{})
+	return vv.version
+}
+
+// Version returns the current version.
+func (vv *VersionedValue[T]) Version() uint64 {
+	vv.mu.Lock()
+	defer vv.mu.Unlock()
+	return vv.version
+}
+
+// WaitVersion blocks till the version is at least minVersion and returns the value and its version at that time,
+// which may be past minVersion. It returns ctx.Err() if ctx is done first.
+func (vv *VersionedValue[T]) WaitVersion(ctx context.Context, minVersion uint64) (T, uint64, error) {
+	for {
+		vv.mu.Lock()
+		v, version, changed := vv.value, vv.version, vv.changed
+		vv.mu.Unlock()
+		if version >= minVersion {
+			return v, version, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, version, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedValue(t *testing.T) {
+	vv := NewVersionedValue("a")
+	v, version := vv.Get()
+	assert.Equal(t, "a", v)
+	assert.Equal(t, uint64(0), version)
+
+	assert.Equal(t, uint64(1), vv.Set("b"))
+	assert.Equal(t, uint64(1), vv.Version())
+
+	v, version, err := vv.WaitVersion(context.Background(), 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "b", v)
+	assert.Equal(t, uint64(1), version)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, version, err = vv.WaitVersion(ctx, 2)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, uint64(1), version)
+}
+
+func TestVersionedValueWaitVersion(t *testing.T) {
+	vv := NewVersionedValue(0)
+	go func() {
+		for i := 1; i <= 5; i++ {
+			time.Sleep(time.Millisecond)
+			vv.Set(i * 10)
+		}
+	}()
+	v, version, err := vv.WaitVersion(context.Background(), 3)
+	assert.Equal(t, nil, err)
+	assert.GreaterOrEqual(t, version, uint64(3))
+	assert.Equal(t, int(version)*10, v)
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WaitAll blocks till every one of onces is DONE or closed. It returns immediately if no Once is given.
+// This is useful as a readiness gate when a service depends on multiple independent initializations.
+func WaitAll(onces ...*Once) {
+	WaitAllContext(context.Background(), onces...)
+}
+
+// WaitAllContext is same as WaitAll but gives up when ctx is done, in which case ctx.Err() is returned.
+func WaitAllContext(ctx context.Context, onces ...*Once) error {
+	for _, o := range onces {
+		o.wait(ctx)
+		if atomic.LoadUint32(&o.done) == 0 && atomic.LoadUint32(&o.unblock) == 0 {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WaitAny blocks till any one of onces is DONE or closed and returns its index.
+// It returns -1 immediately if no Once is given.
+func WaitAny(onces ...*Once) int {
+	if len(onces) == 0 {
+		return -1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// buffered so that the goroutines woken up by cancel() don't block
+	ch := make(chan int, len(onces))
+	for i, o := range onces {
+		go func(i int, o *Once) {
+			o.wait(ctx)
+			ch <- i
+		}(i, o)
+	}
+	return <-ch
+}
+
+// RunAll calls DoE() of every one of onces concurrently, waits for all the calls and returns the first error.
+// It's the errgroup pattern applied to Onces which together make up a startup sequence.
+// Use AsErrDoer to pass a Doer which doesn't report errors.
+//
+// The first error cancels the context shared by the calls: calls which haven't started yet are skipped, and calls
+// blocked on a *Once being executed by some other goroutine stop waiting, same as with Once.DoContext.
+// Function/s already executing are not interrupted. If ctx is done before any call fails, ctx.Err() is returned.
+func RunAll(ctx context.Context, onces ...ErrDoer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		first sync.Once
+		err   error
+	)
+	wg.Add(len(onces))
+	for _, o := range onces {
+		go func(o ErrDoer) {
+			defer wg.Done()
+			if e := runE(ctx, o); e != nil {
+				first.Do(func() {
+					err = e
+					cancel()
+				})
+			}
+		}(o)
+	}
+	wg.Wait()
+	return err
+}
+
+// runE calls DoE() of o unless ctx is done. A *Once is waited for only till ctx is done.
+func runE(ctx context.Context, o ErrDoer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d, ok := o.(*Once)
+	if !ok {
+		_, err := o.DoE()
+		return err
+	}
+	if _, err := d.DoContext(ctx); err != nil {
+		return err
+	}
+	return d.Err()
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitAll(t *testing.T) {
+	WaitAll()
+
+	o1, err := NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*2))
+	assert.Equal(t, err, nil)
+	o2, err := NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*4))
+	assert.Equal(t, err, nil)
+	o3, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+
+	go o1.Do()
+	go o2.Do()
+	go func() { time.Sleep(time.Millisecond); o3.Close() }()
+
+	WaitAll(o1, o2, o3)
+	assert.Equal(t, true, o1.Done(false))
+	assert.Equal(t, true, o2.Done(false))
+	assert.Equal(t, false, o3.Done(false))
+}
+
+func TestWaitAllContext(t *testing.T) {
+	assert.Equal(t, WaitAllContext(context.Background()), nil)
+
+	o1, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o2, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o1.Do()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*2)
+	defer cancel()
+	ts := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, WaitAllContext(ctx, o1, o2))
+	assert.True(t, time.Now().Sub(ts) >= time.Millisecond*2)
+
+	o2.Do()
+	assert.Equal(t, WaitAllContext(context.Background(), o1, o2), nil)
+}
+
+func TestWaitAny(t *testing.T) {
+	assert.Equal(t, -1, WaitAny())
+
+	o1, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	o2, err := NewOnce(true, false, VerifyNone, returnTrueWithDelay(time.Millisecond*2))
+	assert.Equal(t, err, nil)
+
+	go o2.Do()
+	assert.Equal(t, 1, WaitAny(o1, o2))
+	assert.Equal(t, false, o1.Done(false))
+}
+
+func TestRunAll(t *testing.T) {
+	assert.Equal(t, nil, RunAll(context.Background()))
+
+	ok1, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	ok2, err := NewDefaultOnce(returnFalse)
+	assert.Equal(t, err, nil)
+	z, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, nil, RunAll(context.Background(), ok1, ok2, AsErrDoer(z)))
+	assert.Equal(t, true, ok1.Done(false))
+	assert.Equal(t, true, ok2.Done(false))
+	assert.Equal(t, true, z.Done(false))
+
+	failing, err := NewOnce(false, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+	ok3, err := NewDefaultOnce(returnTrue) // may or may not get to run before failing cancels the rest
+	assert.Equal(t, err, nil)
+	err = RunAll(context.Background(), ok3, failing)
+	assert.True(t, errors.Is(err, ErrPanicked))
+
+	// calls are skipped once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	notRun, err := NewDefaultOnce(returnTrue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, context.Canceled, RunAll(ctx, notRun))
+	assert.Equal(t, uint64(0), notRun.Count())
+}
+
+func TestRunAllStopsWaiting(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow, err := NewOnce(true, false, VerifyNone, func() bool {
+		<-release
+		return true
+	})
+	assert.Equal(t, err, nil)
+	go slow.Do()
+	slow.WaitReady()
+
+	failing, err := NewOnce(false, true, VerifyNone, doPanic)
+	assert.Equal(t, err, nil)
+
+	// RunAll would block on slow till release is closed if the failure didn't signal it to stop
+	err = RunAll(context.Background(), slow, failing)
+	assert.True(t, errors.Is(err, ErrPanicked))
+	assert.Equal(t, false, slow.Done(false))
+}
@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// closedCh is a closed channel, returned to callers which don't need to wait.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// WaitGroup is the WaitGroup of golang's sync package with waits which can be bounded by a timeout or a context,
+// and a channel which is closed when the counter is zero, for use in select. Its zero value is ready to use.
+// A WaitGroup must not be copied after first use.
+type WaitGroup struct {
+	mu sync.Mutex
+	n  int64
+	ch chan struct{} // closed when n gets to zero. Created by the first waiter. guarded by mu
+}
+
+// Add adds delta, which may be negative, to the counter. If the counter becomes zero, all goroutines blocked in
+// Wait() and its variants are released. Add panics if the counter goes negative.
+func (wg *WaitGroup) Add(delta int) {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	wg.n += int64(delta)
+	if wg.n < 0 {
+		wg.n -= int64(delta)
+		panic("sync: negative WaitGroup counter")
+	}
+	if wg.n == 0 && wg.ch != nil {
+		close(wg.ch)
+		wg.ch = nil
+	}
+}
+
+// Done decrements the counter by one.
+func (wg *WaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Wait blocks till the counter is zero.
+func (wg *WaitGroup) Wait() {
+	<-wg.WaitChan()
+}
+
+// WaitTimeout is same as Wait() but gives up after timeout. It returns true if the counter became zero.
+func (wg *WaitGroup) WaitTimeout(timeout time.Duration) bool {
+	return wg.WaitTimeoutWithClock(RealClock{}, timeout)
+}
+
+// WaitTimeoutWithClock is same as WaitTimeout() but the timeout is measured by clock.
+func (wg *WaitGroup) WaitTimeoutWithClock(clock Clock, timeout time.Duration) bool {
+	ch := wg.WaitChan()
+	select {
+	case <-ch:
+		return true
+	default:
+	}
+	t := clock.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-ch:
+		return true
+	case <-t.C():
+		return false
+	}
+}
+
+// WaitCtx is same as Wait() but gives up when ctx is done, in which case ctx.Err() is returned.
+func (wg *WaitGroup) WaitCtx(ctx context.Context) error {
+	select {
+	case <-wg.WaitChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitChan returns a channel which is closed when the counter is zero. If the counter is zero already, the channel
+// is closed right away. A channel obtained before the counter drops to zero isn't affected by later calls to Add.
+func (wg *WaitGroup) WaitChan() <-chan struct{} {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	if wg.n == 0 {
+		return closedCh
+	}
+	if wg.ch == nil {
+		wg.ch = make(chan struct{})
+	}
+	return wg.ch
+}
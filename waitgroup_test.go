@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitGroup(t *testing.T) {
+	var wg WaitGroup
+	wg.Wait()
+	assert.Equal(t, true, wg.WaitTimeout(0))
+
+	var done int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&done, 1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), atomic.LoadInt32(&done))
+	assert.Panics(t, func() { wg.Done() })
+}
+
+func TestWaitGroupTimeout(t *testing.T) {
+	var wg WaitGroup
+	wg.Add(1)
+	c := NewManualClock(clockEpoch)
+	assert.Equal(t, false, advanceWhile(c, time.Hour, func() bool { return wg.WaitTimeoutWithClock(c, time.Hour) }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, wg.WaitCtx(ctx))
+
+	go wg.Done()
+	assert.Equal(t, true, wg.WaitTimeoutWithClock(c, time.Hour))
+	assert.Equal(t, nil, wg.WaitCtx(context.Background()))
+}
+
+func TestWaitGroupChan(t *testing.T) {
+	var wg WaitGroup
+	select {
+	case <-wg.WaitChan():
+	default:
+		t.Fatal("channel of a zero counter isn't closed")
+	}
+
+	wg.Add(2)
+	ch := wg.WaitChan()
+	wg.Done()
+	select {
+	case <-ch:
+		t.Fatal("channel closed before the counter is zero")
+	default:
+	}
+	wg.Done()
+	<-ch
+
+	// a new round uses a new channel
+	wg.Add(1)
+	select {
+	case <-wg.WaitChan():
+		t.Fatal("channel of a new round is closed")
+	default:
+	}
+	wg.Done()
+}
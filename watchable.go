@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"sync"
+)
+
+// Watchable holds a value and notifies goroutines of its changes: they can Watch() the updates or wait till the
+// value satisfies a condition with WaitFor(). It's a generalization of Once.Done, which waits for a single state
+// change, to waiting for any state. Clients should use NewWatchable to create objects.
+type Watchable[T any] struct {
+	mu      sync.Mutex
+	value   T             // guarded by mu
+	version uint64        // incremented by each Set. guarded by mu
+	changed chan struct{} // closed by the next Set, then replaced. guarded by mu
+}
+
+// NewWatchable returns a Watchable holding v.
+func NewWatchable[T any](v T) *Watchable[T] {
+	return &Watchable[T]{value: v, changed: make(chan struct{})}
+}
+
+// Get returns the value.
+func (w *Watchable[T]) Get() T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.value
+}
+
+// Set sets the value to v and notifies the watchers, even if v is equal to the current value.
+func (w *Watchable[T]) Set(v T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.value = v
+	w.version++
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// Watch returns a channel receiving the current value and then the value after each Set(), till ctx is done when
+// the channel is closed. A watcher which doesn't keep up skips to the latest value instead of receiving every one.
+func (w *Watchable[T]) Watch(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		sent := false
+		var last uint64
+		for {
+			v, version, changed := w.snapshot()
+			if sent && version == last {
+				select {
+				case <-changed:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			select {
+			case ch <- v:
+				sent, last = true, version
+			case <-changed:
+				// a newer value replaces the one not received yet
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// WaitFor blocks till the value satisfies pred and returns it, or returns ctx.Err() if ctx is done first.
+// pred is called with the current value and then after each Set(), without holding any lock.
+func (w *Watchable[T]) WaitFor(ctx context.Context, pred func(T) bool) (T, error) {
+	for {
+		v, _, changed := w.snapshot()
+		if pred(v) {
+			return v, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// snapshot returns the value, its version and the channel closed by the next Set.
+func (w *Watchable[T]) snapshot() (T, uint64, <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.value, w.version, w.changed
+}
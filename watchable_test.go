@@ -0,0 +1,50 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchable(t *testing.T) {
+	w := NewWatchable("a")
+	assert.Equal(t, "a", w.Get())
+	w.Set("b")
+	assert.Equal(t, "b", w.Get())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := w.Watch(ctx)
+	assert.Equal(t, "b", <-ch)
+	w.Set("c")
+	assert.Equal(t, "c", <-ch)
+
+	// a slow watcher skips to the latest value
+	w.Set("d")
+	w.Set("e")
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "e", <-ch)
+
+	cancel()
+	_, ok := <-ch
+	assert.Equal(t, false, ok)
+}
+
+func TestWatchableWaitFor(t *testing.T) {
+	w := NewWatchable(0)
+	go func() {
+		for i := 1; i <= 10; i++ {
+			time.Sleep(time.Millisecond)
+			w.Set(i)
+		}
+	}()
+	v, err := w.WaitFor(context.Background(), func(v int) bool { return v >= 5 })
+	assert.Equal(t, nil, err)
+	assert.GreaterOrEqual(t, v, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = w.WaitFor(ctx, func(v int) bool { return v > 10 })
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
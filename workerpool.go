@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkerPool executes submitted tasks on a bounded number of worker goroutines, queueing the tasks submitted while
+// all the workers are busy. The number of workers can be changed with Resize(). Shutdown() stops the intake and
+// drains the queue, exactly once as it's enforced by a Once. Clients should use NewWorkerPool to create objects.
+type WorkerPool struct {
+	tasks   chan func()
+	closing chan struct{} // closed first by Shutdown(), to unblock the submitters
+	sendMu  sync.RWMutex  // held for reading while submitting, so that tasks isn't closed during a send
+	wg      WaitGroup     // running workers
+	o       *Once
+
+	mu     sync.Mutex
+	stops  []chan struct{} // one per worker, closed to make it exit. guarded by mu
+	closed bool            // guarded by mu
+
+	inFlight  int64
+	completed uint64
+	panicked  uint64
+}
+
+// WorkerPoolStats is a snapshot of the metrics of a WorkerPool. See WorkerPool.Stats.
+type WorkerPoolStats struct {
+	Workers   int    // workers, same as Workers()
+	Queued    int    // tasks waiting for a worker
+	InFlight  int    // tasks being executed
+	Completed uint64 // tasks executed, including the ones which panicked
+	Panicked  uint64 // tasks which panicked
+}
+
+// NewWorkerPool returns a WorkerPool with the given number of workers and a queue holding up to queueSize tasks.
+// With a queueSize of 0, a submit blocks till a worker takes the task. An error is returned if workers isn't
+// positive or queueSize is negative.
+func NewWorkerPool(workers, queueSize int) (*WorkerPool, error) {
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers needs to be positive, got %d", workers)
+	}
+	if queueSize < 0 {
+		return nil, fmt.Errorf("queue size can't be negative, got %d", queueSize)
+	}
+	p := &WorkerPool{tasks: make(chan func(), queueSize), closing: make(chan struct{})}
+	// lazyDone = true, so the WorkerPool becomes DONE only after the intake is stopped
+	p.o, _ = NewOnce(true, false, VerifyNone, func() bool {
+		close(p.closing)
+		p.sendMu.Lock()
+		defer p.sendMu.Unlock()
+		close(p.tasks)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.closed = true
+		return true
+	})
+	p.Resize(workers)
+	return p, nil
+}
+
+// Submit queues task, blocking while the queue is full. It returns ErrClosed if the WorkerPool is shut down first.
+func (p *WorkerPool) Submit(task func()) error {
+	return p.SubmitCtx(context.Background(), task)
+}
+
+// SubmitCtx queues task, blocking while the queue is full. It returns ErrClosed if the WorkerPool is shut down first
+// and ctx.Err() if ctx is done first.
+func (p *WorkerPool) SubmitCtx(ctx context.Context, task func()) error {
+	p.sendMu.RLock()
+	defer p.sendMu.RUnlock()
+	select {
+	case <-p.closing:
+		return ErrClosed
+	default:
+	}
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-p.closing:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit queues task if there is room right away, and returns whether it did.
+func (p *WorkerPool) TrySubmit(task func()) bool {
+	p.sendMu.RLock()
+	defer p.sendMu.RUnlock()
+	select {
+	case <-p.closing:
+		return false
+	default:
+	}
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resize changes the number of workers to n. Extra workers exit once they finish their current task.
+// It returns ErrClosed if the WorkerPool is shut down, and an error if n isn't positive.
+func (p *WorkerPool) Resize(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("workers needs to be positive, got %d", n)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	for len(p.stops) < n {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1)
+		go p.work(stop)
+	}
+	for _, stop := range p.stops[n:] {
+		close(stop)
+	}
+	p.stops = p.stops[:n]
+	return nil
+}
+
+// Workers returns the number of workers.
+func (p *WorkerPool) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.stops)
+}
+
+// Shutdown stops the intake, making the pending and later submits fail with ErrClosed, and blocks till the queued
+// tasks are executed and the workers exit. It returns nil once they have, and ctx.Err() if ctx is done first, in
+// which case the draining goes on in the background. Only the first call stops the intake. All the calls wait for
+// the draining.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.o.Do()
+	return p.wg.WaitCtx(ctx)
+}
+
+// Stats returns the metrics of the WorkerPool. They are read one at a time, so while the WorkerPool is in use
+// they may not be consistent with each other.
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	return WorkerPoolStats{
+		Workers:   p.Workers(),
+		Queued:    len(p.tasks),
+		InFlight:  int(atomic.LoadInt64(&p.inFlight)),
+		Completed: atomic.LoadUint64(&p.completed),
+		Panicked:  atomic.LoadUint64(&p.panicked),
+	}
+}
+
+// work executes tasks till the queue is closed and drained, or stop is closed.
+func (p *WorkerPool) work(stop chan struct{}) {
+	defer p.wg.Done()
+	for {
+		select {
+		case task, ok := <-p.tasks:
+			if !ok {
+				return
+			}
+			p.execute(task)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// execute runs task, recovering a panic in it so that the worker survives. The panic is counted in Stats.
+func (p *WorkerPool) execute(task func()) {
+	atomic.AddInt64(&p.inFlight, 1)
+	defer func() {
+		if recover() != nil {
+			atomic.AddUint64(&p.panicked, 1)
+		}
+		atomic.AddInt64(&p.inFlight, -1)
+		atomic.AddUint64(&p.completed, 1)
+	}()
+	task()
+}
@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWorkerPool(t *testing.T) {
+	_, err := NewWorkerPool(0, 1)
+	assert.NotEqual(t, err, nil)
+	_, err = NewWorkerPool(1, -1)
+	assert.NotEqual(t, err, nil)
+
+	p, err := NewWorkerPool(2, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 2, p.Workers())
+	assert.NotEqual(t, nil, p.Resize(0))
+	assert.Equal(t, nil, p.Shutdown(context.Background()))
+}
+
+func TestWorkerPool(t *testing.T) {
+	p, _ := NewWorkerPool(2, 10)
+	var n int32
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, nil, p.Submit(func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&n, 1)
+		}))
+	}
+	assert.Equal(t, nil, p.Submit(func() { panic("boom") }))
+
+	// the queued tasks are drained
+	assert.Equal(t, nil, p.Shutdown(context.Background()))
+	assert.Equal(t, int32(20), atomic.LoadInt32(&n))
+	assert.Equal(t, WorkerPoolStats{Workers: 2, Completed: 21, Panicked: 1}, p.Stats())
+
+	assert.Equal(t, ErrClosed, p.Submit(func() {}))
+	assert.Equal(t, false, p.TrySubmit(func() {}))
+	assert.Equal(t, ErrClosed, p.Resize(3))
+	assert.Equal(t, nil, p.Shutdown(context.Background()))
+}
+
+func TestWorkerPoolBlockingSubmit(t *testing.T) {
+	p, _ := NewWorkerPool(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	assert.Equal(t, true, p.TrySubmit(func() {}))
+	assert.Equal(t, false, p.TrySubmit(func() {}))
+	s := p.Stats()
+	assert.Equal(t, 1, s.InFlight)
+	assert.Equal(t, 1, s.Queued)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, p.SubmitCtx(ctx, func() {}))
+
+	// Shutdown unblocks a blocked submit, and times out while the task runs
+	errs := make(chan error)
+	go func() { errs <- p.Submit(func() {}) }()
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, p.Shutdown(ctx))
+	assert.Equal(t, ErrClosed, <-errs)
+
+	close(release)
+	assert.Equal(t, nil, p.Shutdown(context.Background()))
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	p, _ := NewWorkerPool(1, 0)
+	var running, peak int32
+	task := func() {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			pk := atomic.LoadInt32(&peak)
+			if r <= pk || atomic.CompareAndSwapInt32(&peak, pk, r) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	}
+	assert.Equal(t, nil, p.Resize(4))
+	assert.Equal(t, 4, p.Workers())
+	for i := 0; i < 8; i++ {
+		p.Submit(task)
+	}
+	assert.Equal(t, nil, p.Resize(2))
+	assert.Equal(t, 2, p.Workers())
+	assert.Equal(t, nil, p.Shutdown(context.Background()))
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(4))
+	assert.Greater(t, atomic.LoadInt32(&peak), int32(1))
+}